@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/appleboy/go-whisper/cache"
+
+	"github.com/dustin/go-humanize"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+)
+
+func cacheCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "cache",
+		Usage: "manage the result cache",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "stats",
+				Usage:  "show the number and the size of the cached results",
+				Action: cacheStats,
+			},
+			{
+				Name:  "prune",
+				Usage: "evict the least recently used results above the size limit",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "remove every cached result",
+					},
+				},
+				Action: cachePrune,
+			},
+		},
+	}
+}
+
+func newCache(c *cli.Context) (*cache.Cache, error) {
+	cfg := newSetting(c)
+	results, err := cache.New(&cfg.Cache)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		return nil, errors.New("cache directory is required, e.g. go-whisper --cache-dir ~/.cache/go-whisper/results cache stats")
+	}
+
+	return results, nil
+}
+
+func cacheStats(c *cli.Context) error {
+	results, err := newCache(c)
+	if err != nil {
+		return err
+	}
+
+	s, err := results.Stats()
+	if err != nil {
+		return err
+	}
+
+	limit := "none"
+	if s.MaxSize > 0 {
+		limit = humanize.Bytes(uint64(s.MaxSize))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DIR\tENTRIES\tSIZE\tMAX SIZE")
+	fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", s.Dir, s.Entries, humanize.Bytes(uint64(s.Size)), limit)
+
+	return w.Flush()
+}
+
+func cachePrune(c *cli.Context) error {
+	results, err := newCache(c)
+	if err != nil {
+		return err
+	}
+
+	s, err := results.Stats()
+	if err != nil {
+		return err
+	}
+
+	maxSize := s.MaxSize
+	if c.Bool("all") {
+		maxSize = 0
+	} else if maxSize <= 0 {
+		return errors.New("cache has no size limit, set --cache-max-size or use --all")
+	}
+
+	removed, freed, err := results.Prune(maxSize)
+	if err != nil {
+		return err
+	}
+	log.Info().
+		Int("removed", removed).
+		Str("freed", humanize.Bytes(uint64(freed))).
+		Msg("cache pruned")
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	entriesDir = "entries"
+	sumsDir    = "sums"
+	entryExt   = ".json"
+)
+
+// Cache stores the decoded results by the hash of the audio and the decoding
+// settings. The least recently used entries are evicted above the size limit.
+type Cache struct {
+	dir     string
+	maxSize int64
+}
+
+// Stats describes the entries of the cache.
+type Stats struct {
+	Dir     string
+	Entries int
+	Size    int64
+	MaxSize int64
+}
+
+// New creates the cache in the configured directory.
+// It returns nil if no directory is configured.
+func New(cfg *config.Cache) (*Cache, error) {
+	if cfg.Dir == "" {
+		return nil, nil
+	}
+
+	var maxSize int64
+	if cfg.MaxSize != "" {
+		n, err := humanize.ParseBytes(cfg.MaxSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache max size %q: %w", cfg.MaxSize, err)
+		}
+		maxSize = int64(n)
+	}
+
+	for _, dir := range []string{entriesDir, sumsDir} {
+		if err := os.MkdirAll(filepath.Join(cfg.Dir, dir), 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Cache{dir: cfg.Dir, maxSize: maxSize}, nil
+}
+
+// Get returns the entry of the key and marks it as recently used.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return data, true
+}
+
+// Put stores the entry of the key and evicts the least recently used
+// entries if the cache exceeds its size limit, if any.
+func (c *Cache) Put(key string, data []byte) error {
+	if err := writeFile(c.path(key), data); err != nil {
+		return err
+	}
+
+	if c.maxSize <= 0 {
+		return nil
+	}
+	_, _, err := c.Prune(c.maxSize)
+	return err
+}
+
+// Stats returns the number and the size of the entries.
+func (c *Cache) Stats() (Stats, error) {
+	entries, err := c.entries()
+	if err != nil {
+		return Stats{}, err
+	}
+
+	s := Stats{Dir: c.dir, Entries: len(entries), MaxSize: c.maxSize}
+	for _, e := range entries {
+		s.Size += e.Size()
+	}
+	return s, nil
+}
+
+// Prune removes the least recently used entries until the cache fits in
+// maxSize bytes, 0 removes every entry. It returns the number of removed
+// entries and the freed bytes.
+func (c *Cache) Prune(maxSize int64) (int, int64, error) {
+	entries, err := c.entries()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var size int64
+	for _, e := range entries {
+		size += e.Size()
+	}
+
+	// oldest first
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+
+	var (
+		removed int
+		freed   int64
+	)
+	for _, e := range entries {
+		if maxSize > 0 && size <= maxSize {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, entriesDir, e.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, freed, err
+		}
+		size -= e.Size()
+		freed += e.Size()
+		removed++
+	}
+
+	return removed, freed, nil
+}
+
+// FileSum returns the SHA-256 digest of the file. The digest is remembered
+// with the size and modification time of the file, so it is only hashed
+// again after it changed.
+func (c *Cache) FileSum(name string) (string, error) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return "", err
+	}
+	stat, err := os.Stat(abs)
+	if err != nil {
+		return "", err
+	}
+
+	id := sha256.Sum256([]byte(abs))
+	stamp := filepath.Join(c.dir, sumsDir, hex.EncodeToString(id[:]))
+	prefix := strconv.FormatInt(stat.Size(), 10) + " " + strconv.FormatInt(stat.ModTime().UnixNano(), 10) + " "
+	if data, err := os.ReadFile(stamp); err == nil && strings.HasPrefix(string(data), prefix) {
+		return strings.TrimSpace(strings.TrimPrefix(string(data), prefix)), nil
+	}
+
+	f, err := os.Open(abs)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	// the stamp is only an optimization, the digest is valid anyway
+	_ = writeFile(stamp, []byte(prefix+sum+"\n"))
+	return sum, nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, entriesDir, key+entryExt)
+}
+
+// entries returns the stored entries, skipping the partial writes.
+func (c *Cache) entries() ([]os.FileInfo, error) {
+	dirEntries, err := os.ReadDir(filepath.Join(c.dir, entriesDir))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]os.FileInfo, 0, len(dirEntries))
+	for _, d := range dirEntries {
+		if d.IsDir() || filepath.Ext(d.Name()) != entryExt {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, info)
+	}
+	return entries, nil
+}
+
+// writeFile replaces the file atomically, so readers never see a partial entry.
+func writeFile(name string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
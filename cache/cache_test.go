@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+)
+
+func newTestCache(t *testing.T, maxSize string) *Cache {
+	t.Helper()
+
+	c, err := New(&config.Cache{Dir: t.TempDir(), MaxSize: maxSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// age makes the entry look used d ago.
+func age(t *testing.T, c *Cache, key string, d time.Duration) {
+	t.Helper()
+
+	past := time.Now().Add(-d)
+	if err := os.Chtimes(c.path(key), past, past); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Cache
+		want    int64
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "disabled", wantNil: true},
+		{name: "size limit", cfg: config.Cache{Dir: t.TempDir(), MaxSize: "10MB"}, want: 10_000_000},
+		{name: "no size limit", cfg: config.Cache{Dir: t.TempDir()}},
+		{name: "invalid size limit", cfg: config.Cache{Dir: t.TempDir(), MaxSize: "ten"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (c == nil) != tt.wantNil {
+				t.Fatalf("New() = %v, wantNil %v", c, tt.wantNil)
+			}
+			if c != nil && c.maxSize != tt.want {
+				t.Errorf("maxSize = %d, want %d", c.maxSize, tt.want)
+			}
+		})
+	}
+}
+
+func TestCache_PutGet(t *testing.T) {
+	c := newTestCache(t, "")
+
+	if _, ok := c.Get("missing"); ok {
+		t.Fatal("Get() found a missing entry")
+	}
+
+	if err := c.Put("key", []byte(`{"segments":[]}`)); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := c.Get("key")
+	if !ok || string(got) != `{"segments":[]}` {
+		t.Errorf("Get() = %q, %v", got, ok)
+	}
+
+	s, err := c.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Entries != 1 || s.Size != int64(len(got)) {
+		t.Errorf("Stats() = %+v, want 1 entry of %d bytes", s, len(got))
+	}
+}
+
+func TestCache_Evict(t *testing.T) {
+	c := newTestCache(t, "25B")
+	data := []byte("0123456789")
+
+	for _, key := range []string{"a", "b"} {
+		if err := c.Put(key, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	age(t, c, "a", 2*time.Hour)
+	age(t, c, "b", time.Hour)
+
+	// reading a makes b the least recently used entry
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("Get() missed a")
+	}
+	if err := c.Put("c", data); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := c.Get(key); ok != want {
+			t.Errorf("Get(%q) found = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestCache_Prune(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxSize     int64
+		wantRemoved int
+		wantFreed   int64
+	}{
+		{name: "within the limit", maxSize: 100},
+		{name: "above the limit", maxSize: 15, wantRemoved: 2, wantFreed: 20},
+		{name: "everything", maxSize: 0, wantRemoved: 3, wantFreed: 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCache(t, "")
+			for i, key := range []string{"old", "mid", "new"} {
+				if err := c.Put(key, []byte("0123456789")); err != nil {
+					t.Fatal(err)
+				}
+				age(t, c, key, time.Duration(3-i)*time.Hour)
+			}
+
+			removed, freed, err := c.Prune(tt.maxSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if removed != tt.wantRemoved || freed != tt.wantFreed {
+				t.Errorf("Prune() = %d, %d, want %d, %d", removed, freed, tt.wantRemoved, tt.wantFreed)
+			}
+			if tt.wantRemoved == 2 {
+				if _, ok := c.Get("new"); !ok {
+					t.Error("Prune() removed the most recent entry")
+				}
+			}
+		})
+	}
+}
+
+func TestCache_FileSum(t *testing.T) {
+	c := newTestCache(t, "")
+	name := filepath.Join(t.TempDir(), "model.bin")
+	if err := os.WriteFile(name, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	const want = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	for range 2 {
+		got, err := c.FileSum(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("FileSum() = %s, want %s", got, want)
+		}
+	}
+
+	// a changed file is hashed again
+	later := time.Now().Add(time.Hour)
+	if err := os.WriteFile(name, []byte("world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(name, later, later); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.FileSum(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == want {
+		t.Error("FileSum() returned the digest of the previous content")
+	}
+}
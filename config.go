@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/whisper"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// fileFlags are the global flags that can't be set from a configuration file.
+var fileFlags = map[string]bool{
+	"config":  true,
+	"profile": true,
+	"help":    true,
+	"version": true,
+}
+
+// loadConfig applies the configuration file to the global flags that aren't
+// set on the command line or in the environment, so the precedence is
+// flag, env, file and then the default value.
+func loadConfig(c *cli.Context) error {
+	name := c.String("config")
+	if name == "" {
+		if c.String("profile") != "" {
+			return errors.New("profile requires a config file, set it with --config")
+		}
+		return nil
+	}
+
+	values, err := config.ReadFile(name, c.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	// an alias is set through the flag name, e.g. download-insecure is youtube-insecure
+	known := map[string]string{}
+	for _, f := range c.App.Flags {
+		for _, alias := range f.Names() {
+			known[alias] = f.Names()[0]
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		flag, ok := known[key]
+		if !ok || fileFlags[flag] {
+			section, k := config.Section(key)
+			return fmt.Errorf("unknown option %s.%s in %s", section, k, name)
+		}
+		if c.IsSet(flag) {
+			continue
+		}
+		for _, v := range values[key] {
+			if err := c.Set(flag, v); err != nil {
+				return fmt.Errorf("invalid value %q for %s in %s: %w", v, key, name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func configCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "check and print the effective configuration",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "validate",
+				Usage:  "validate the configuration without transcribing",
+				Action: configValidate,
+			},
+			{
+				Name:   "print",
+				Usage:  "print the effective configuration with the secrets redacted",
+				Action: configPrint,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "output format, support yaml, toml",
+						Value: "yaml",
+					},
+				},
+			},
+		},
+	}
+}
+
+func configValidate(c *cli.Context) error {
+	cfg := newSetting(c)
+
+	if err := resolveModel(&cfg); err != nil {
+		return err
+	}
+
+	// the audio path is usually given per run
+	check := cfg.Whisper
+	if check.AudioPath == "" && cfg.Youtube.URL == "" {
+		check.AudioPath = "-"
+	}
+	if cfg.Youtube.URL != "" {
+		check.AudioPath = cfg.Youtube.URL
+	}
+	if err := check.Validate(); err != nil {
+		return err
+	}
+	if err := whisper.CheckFormats(cfg.Whisper.OutputFormat); err != nil {
+		return err
+	}
+
+	fmt.Println("configuration is valid")
+	return nil
+}
+
+func configPrint(c *cli.Context) error {
+	cfg := newSetting(c)
+	redacted := config.Redact(cfg)
+
+	sections := map[string]map[string]any{}
+	for _, f := range c.App.Flags {
+		name := f.Names()[0]
+		if fileFlags[name] {
+			continue
+		}
+
+		section, key := config.Section(name)
+		if sections[section] == nil {
+			sections[section] = map[string]any{}
+		}
+		if path, ok := secretFields[name]; ok {
+			sections[section][key] = redactedField(redacted, path)
+			continue
+		}
+		sections[section][key] = flagValue(c, f)
+	}
+
+	switch c.String("format") {
+	case "yaml":
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		defer enc.Close()
+		return enc.Encode(sections)
+	case "toml":
+		return toml.NewEncoder(os.Stdout).Encode(sections)
+	}
+
+	return fmt.Errorf("unsupported format: %s", c.String("format"))
+}
+
+// flagValue returns the effective value of the flag in the configuration file format.
+func flagValue(c *cli.Context, f cli.Flag) any {
+	name := f.Names()[0]
+	switch f.(type) {
+	case *cli.StringSliceFlag:
+		v := c.StringSlice(name)
+		if v == nil {
+			v = []string{}
+		}
+		return v
+	case *cli.DurationFlag:
+		return c.Duration(name).String()
+	case *cli.UintFlag:
+		return c.Uint(name)
+	}
+
+	return c.Value(name)
+}
+
+// secretFields maps the flags holding a secret to the path of their field in
+// the configuration, configPrint prints them as config.Redact masks the field.
+var secretFields = map[string]string{
+	"audio-path":      "Whisper.AudioPath",
+	"webhook-url":     "Webhook.URL",
+	"webhook-headers": "Webhook.Headers",
+	"youtube-url":     "Youtube.URL",
+	"s3-access-key":   "S3.AccessKey",
+	"s3-secret-key":   "S3.SecretKey",
+	"model-base-url":  "Models.BaseURL",
+	"otlp-endpoint":   "Tracing.Endpoint",
+}
+
+// redactedField returns the field at the dotted path of a config.Redact tree.
+func redactedField(redacted any, path string) any {
+	for _, name := range strings.Split(path, ".") {
+		fields, ok := redacted.(map[string]any)
+		if !ok {
+			return nil
+		}
+		redacted = fields[name]
+	}
+	return redacted
+}
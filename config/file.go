@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Sections are the groups of a configuration file. The keys of a section are
+// the command line flags without the section prefix, e.g. webhook.url is
+// --webhook-url. The keys of the whisper section are the flags as is.
+var Sections = []string{"whisper", "webhook", "youtube", "download", "s3", "model"}
+
+// profilesKey holds the named profiles in a configuration file.
+const profilesKey = "profiles"
+
+// ReadFile reads a YAML or TOML configuration file and returns the values by flag name.
+// If profile is set, the options of the named profile override the top-level options.
+func ReadFile(name, profile string) (map[string][]string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]any{}
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file %s, use .yaml, .yml or .toml", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", name, err)
+	}
+
+	profiles, _ := raw[profilesKey].(map[string]any)
+	delete(raw, profilesKey)
+
+	values := map[string][]string{}
+	if err := flatten(values, "", raw); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", name, err)
+	}
+
+	if profile == "" {
+		return values, nil
+	}
+
+	p, ok := profiles[profile].(map[string]any)
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("profile %q not found in %s, available profiles: %s",
+			profile, name, strings.Join(names, ", "))
+	}
+	if err := flatten(values, "", p); err != nil {
+		return nil, fmt.Errorf("profile %s: %w", profile, err)
+	}
+
+	return values, nil
+}
+
+// flatten joins the nested keys with a dash, dropping the whisper section.
+func flatten(values map[string][]string, prefix string, m map[string]any) error {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "-" + k
+		}
+		if prefix == "" && k == "whisper" {
+			key = ""
+		}
+
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flatten(values, key, v); err != nil {
+				return err
+			}
+		case []any:
+			list := make([]string, len(v))
+			for i, item := range v {
+				list[i] = fmt.Sprint(item)
+			}
+			values[key] = list
+		case nil:
+			return fmt.Errorf("missing value for %s", key)
+		default:
+			if key == "" {
+				return fmt.Errorf("whisper must be a section")
+			}
+			values[key] = []string{fmt.Sprint(v)}
+		}
+	}
+
+	return nil
+}
+
+// Section returns the section and the key of a flag in a configuration file.
+func Section(flag string) (string, string) {
+	for _, section := range Sections[1:] {
+		if key, ok := strings.CutPrefix(flag, section+"-"); ok {
+			return section, key
+		}
+	}
+
+	return Sections[0], flag
+}
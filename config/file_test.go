@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testYAML = `
+whisper:
+  model: small
+  language: en
+  output-format: [txt, srt]
+  vad: true
+  vad-threshold: -40
+webhook:
+  url: https://example.com/hook
+  headers:
+    - "Authorization=Bearer token"
+profiles:
+  podcast:
+    whisper:
+      language: auto
+      clean: true
+  call-center:
+    whisper:
+      model: base.en
+    webhook:
+      url: https://example.com/calls
+`
+
+const testTOML = `
+[whisper]
+model = "small"
+language = "en"
+output-format = ["txt", "srt"]
+vad = true
+vad-threshold = -40
+
+[webhook]
+url = "https://example.com/hook"
+headers = ["Authorization=Bearer token"]
+
+[profiles.podcast.whisper]
+language = "auto"
+clean = true
+`
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yaml": testYAML,
+		"config.toml": testTOML,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	base := map[string][]string{
+		"model":           {"small"},
+		"language":        {"en"},
+		"output-format":   {"txt", "srt"},
+		"vad":             {"true"},
+		"vad-threshold":   {"-40"},
+		"webhook-url":     {"https://example.com/hook"},
+		"webhook-headers": {"Authorization=Bearer token"},
+	}
+	podcast := map[string][]string{
+		"model":           {"small"},
+		"language":        {"auto"},
+		"clean":           {"true"},
+		"output-format":   {"txt", "srt"},
+		"vad":             {"true"},
+		"vad-threshold":   {"-40"},
+		"webhook-url":     {"https://example.com/hook"},
+		"webhook-headers": {"Authorization=Bearer token"},
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		profile string
+		want    map[string][]string
+		wantErr bool
+	}{
+		{name: "yaml", file: "config.yaml", want: base},
+		{name: "toml", file: "config.toml", want: base},
+		{name: "yaml profile", file: "config.yaml", profile: "podcast", want: podcast},
+		{name: "toml profile", file: "config.toml", profile: "podcast", want: podcast},
+		{name: "unknown profile", file: "config.yaml", profile: "radio", wantErr: true},
+		{name: "missing file", file: "missing.yaml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadFile(filepath.Join(dir, tt.file), tt.profile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSection(t *testing.T) {
+	tests := []struct {
+		flag        string
+		wantSection string
+		wantKey     string
+	}{
+		{flag: "model", wantSection: "whisper", wantKey: "model"},
+		{flag: "vad-threshold", wantSection: "whisper", wantKey: "vad-threshold"},
+		{flag: "model-dir", wantSection: "model", wantKey: "dir"},
+		{flag: "webhook-url", wantSection: "webhook", wantKey: "url"},
+		{flag: "download-retry-count", wantSection: "download", wantKey: "retry-count"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			section, key := Section(tt.flag)
+			if section != tt.wantSection || key != tt.wantKey {
+				t.Errorf("Section() = %v, %v, want %v, %v", section, key, tt.wantSection, tt.wantKey)
+			}
+		})
+	}
+}
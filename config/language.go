@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Languages maps the language codes supported by whisper to their names.
+var Languages = map[string]string{
+	"en": "english", "zh": "chinese", "de": "german", "es": "spanish",
+	"ru": "russian", "ko": "korean", "fr": "french", "ja": "japanese",
+	"pt": "portuguese", "tr": "turkish", "pl": "polish", "ca": "catalan",
+	"nl": "dutch", "ar": "arabic", "sv": "swedish", "it": "italian",
+	"id": "indonesian", "hi": "hindi", "fi": "finnish", "vi": "vietnamese",
+	"he": "hebrew", "uk": "ukrainian", "el": "greek", "ms": "malay",
+	"cs": "czech", "ro": "romanian", "da": "danish", "hu": "hungarian",
+	"ta": "tamil", "no": "norwegian", "th": "thai", "ur": "urdu",
+	"hr": "croatian", "bg": "bulgarian", "lt": "lithuanian", "la": "latin",
+	"mi": "maori", "ml": "malayalam", "cy": "welsh", "sk": "slovak",
+	"te": "telugu", "fa": "persian", "lv": "latvian", "bn": "bengali",
+	"sr": "serbian", "az": "azerbaijani", "sl": "slovenian", "kn": "kannada",
+	"et": "estonian", "mk": "macedonian", "br": "breton", "eu": "basque",
+	"is": "icelandic", "hy": "armenian", "ne": "nepali", "mn": "mongolian",
+	"bs": "bosnian", "kk": "kazakh", "sq": "albanian", "sw": "swahili",
+	"gl": "galician", "mr": "marathi", "pa": "punjabi", "si": "sinhala",
+	"km": "khmer", "sn": "shona", "yo": "yoruba", "so": "somali",
+	"af": "afrikaans", "oc": "occitan", "ka": "georgian", "be": "belarusian",
+	"tg": "tajik", "sd": "sindhi", "gu": "gujarati", "am": "amharic",
+	"yi": "yiddish", "lo": "lao", "uz": "uzbek", "fo": "faroese",
+	"ht": "haitian creole", "ps": "pashto", "tk": "turkmen", "nn": "nynorsk",
+	"mt": "maltese", "sa": "sanskrit", "lb": "luxembourgish", "my": "myanmar",
+	"bo": "tibetan", "tl": "tagalog", "mg": "malagasy", "as": "assamese",
+	"tt": "tatar", "haw": "hawaiian", "ln": "lingala", "ha": "hausa",
+	"ba": "bashkir", "jw": "javanese", "su": "sundanese",
+}
+
+// ValidateLanguage checks the language is auto or a code supported by whisper.
+// The error suggests the closest codes for a typo or a language name.
+func ValidateLanguage(lang string) error {
+	if lang == "" || lang == "auto" {
+		return nil
+	}
+	if _, ok := Languages[lang]; ok {
+		return nil
+	}
+
+	suggestions := suggestLanguages(lang)
+	if len(suggestions) == 0 {
+		return fmt.Errorf("unknown language %q, use auto or an ISO 639-1 code such as en", lang)
+	}
+
+	return fmt.Errorf("unknown language %q, did you mean %s?", lang, strings.Join(suggestions, ", "))
+}
+
+// suggestLanguages returns the codes close to the given code or language name.
+func suggestLanguages(lang string) []string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+
+	var result []string
+	for code, name := range Languages {
+		switch {
+		case code == lang, name == lang,
+			len(lang) > 2 && strings.HasPrefix(name, lang),
+			len(lang) > 3 && distance(name, lang) <= 2,
+			len(lang) <= 3 && distance(code, lang) <= 1 && lang[0] == code[0]:
+			result = append(result, fmt.Sprintf("%s (%s)", code, name))
+		}
+	}
+	sort.Strings(result)
+
+	if len(result) > 3 {
+		result = result[:3]
+	}
+	return result
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// IsEnglishOnly reports whether the model file is an English-only model,
+// e.g. ggml-base.en.bin.
+func IsEnglishOnly(model string) bool {
+	name := strings.TrimSuffix(filepath.Base(model), filepath.Ext(model))
+	return strings.HasSuffix(name, ".en") || strings.Contains(name, ".en-")
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Redacted replaces the secrets in logs and printed configurations.
+const Redacted = "******"
+
+// Redact returns a copy of the configuration that is safe to log. Fields are
+// masked according to their sensitive tag:
+//
+//	sensitive:"true"     the whole value
+//	sensitive:"url"      the password and the query values of a URL
+//	sensitive:"headers"  the values of Key=Value headers
+func Redact(v any) any {
+	return redactValue(reflect.ValueOf(v), "")
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func redactValue(v reflect.Value, tag string) any {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem(), tag)
+	case reflect.Struct:
+		m := make(map[string]any, v.NumField())
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			m[f.Name] = redactValue(v.Field(i), f.Tag.Get("sensitive"))
+		}
+		return m
+	}
+
+	switch tag {
+	case "true":
+		if !v.IsZero() {
+			return Redacted
+		}
+	case "url":
+		return RedactURL(v.String())
+	case "headers":
+		return RedactHeaders(v.Interface().([]string))
+	}
+
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+
+	return v.Interface()
+}
+
+// RedactURL masks the password and the query values of a URL, e.g. the
+// signature of a presigned link. Anything that isn't a URL is returned as is.
+func RedactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return s
+	}
+
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), Redacted)
+	}
+
+	if u.RawQuery != "" {
+		q := u.Query()
+		for key := range q {
+			q[key] = []string{Redacted}
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	// keep the mask readable instead of percent-encoded
+	return strings.ReplaceAll(u.String(), url.QueryEscape(Redacted), Redacted)
+}
+
+// RedactHeaders masks the values of headers in the Key=Value form.
+func RedactHeaders(headers []string) []string {
+	if headers == nil {
+		return nil
+	}
+
+	result := make([]string, len(headers))
+	for i, h := range headers {
+		key, _, _ := strings.Cut(h, "=")
+		result[i] = key + "=" + Redacted
+	}
+
+	return result
+}
+
+// RedactError masks the URL of a failed HTTP request in the error message.
+func RedactError(err error) error {
+	var e *url.Error
+	if errors.As(err, &e) {
+		e.URL = RedactURL(e.URL)
+	}
+
+	return err
+}
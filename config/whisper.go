@@ -1,11 +1,20 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
+
+// MaxAudioCtx is the audio context size of the whisper models, 30 seconds of audio.
+const MaxAudioCtx = 1500
+
+// MaxBeamSize is the maximum number of decoders whisper runs in parallel.
+const MaxBeamSize = 8
 
 // Whisper is the configuration for whisper.
 type Whisper struct {
 	Model        string
-	AudioPath    string
+	AudioPath    string `sensitive:"url"`
 	Threads      uint
 	Language     string
 	Debug        bool
@@ -16,16 +25,39 @@ type Whisper struct {
 	BeamSize     uint
 	EntropyThold float64
 
+	Temperature    float64 // Temperature is the initial sampling temperature, 0 decodes greedily.
+	TemperatureInc float64 // TemperatureInc is added to the temperature when a decoding fails the thresholds, 0 disables the fallback.
+	BestOf         uint    // BestOf is the number of candidates sampled above zero temperature.
+	LogprobThold   float64 // LogprobThold is the average log probability below which a decoding fails.
+	NoSpeechThold  float64 // NoSpeechThold is the no speech probability above which a segment is silence.
+
+	Offset           time.Duration
+	Duration         time.Duration
+	SplitOnWord      bool
+	MaxSegmentLength uint
+	MaxTokens        uint
+	TokenTimestamps  bool
+	TokenThold       float64
+	TokenSumThold    float64
+	AudioCtx         uint
+
 	PrintProgress bool
 	PrintSegment  bool
 
+	VAD        VAD
+	Clean      Clean
+	Checkpoint Checkpoint
+	Glossary   Glossary
+	Rules      Rules
+
 	OutputFolder   string
 	OutputFilename string
 	OutputFormat   []string
 }
 
 // Validate checks if the Whisper configuration is valid.
-// It returns an error if the audio path or model is missing.
+// It returns an error if the audio path or model is missing, if the language
+// is unknown or not supported by the model, or if a decoding option is out of range.
 func (c *Whisper) Validate() error {
 	if c.AudioPath == "" {
 		return fmt.Errorf("audio path is required")
@@ -35,14 +67,125 @@ func (c *Whisper) Validate() error {
 		return fmt.Errorf("model is required")
 	}
 
+	if err := ValidateLanguage(c.Language); err != nil {
+		return err
+	}
+
+	if IsEnglishOnly(c.Model) {
+		if c.Translate {
+			return fmt.Errorf("translate requires a multilingual model, %s is English-only", c.Model)
+		}
+		if c.Language != "" && c.Language != "auto" && c.Language != "en" {
+			return fmt.Errorf("language %q requires a multilingual model, %s is English-only", c.Language, c.Model)
+		}
+	}
+
+	if c.Threads == 0 {
+		return fmt.Errorf("threads must be greater than 0")
+	}
+
+	if c.BeamSize > MaxBeamSize {
+		return fmt.Errorf("beam size must not exceed %d, got %d", MaxBeamSize, c.BeamSize)
+	}
+
+	if c.BestOf > MaxBeamSize {
+		return fmt.Errorf("best of must not exceed %d, got %d", MaxBeamSize, c.BestOf)
+	}
+
+	if c.Temperature < 0 || c.Temperature > 1 {
+		return fmt.Errorf("temperature must be between 0 and 1, got %v", c.Temperature)
+	}
+
+	if c.TemperatureInc < 0 || c.TemperatureInc > 1 {
+		return fmt.Errorf("temperature increment must be between 0 and 1, got %v", c.TemperatureInc)
+	}
+
+	if c.LogprobThold > 0 {
+		return fmt.Errorf("log probability threshold must not be positive, got %v", c.LogprobThold)
+	}
+
+	if c.NoSpeechThold < 0 || c.NoSpeechThold > 1 {
+		return fmt.Errorf("no speech threshold must be between 0 and 1, got %v", c.NoSpeechThold)
+	}
+
+	if c.VAD.MinSpeech < 0 || c.VAD.MinSilence < 0 || c.VAD.Padding < 0 {
+		return fmt.Errorf("vad durations must not be negative")
+	}
+
+	if c.Offset < 0 || c.Duration < 0 {
+		return fmt.Errorf("offset and duration must not be negative")
+	}
+
+	if c.Checkpoint.Interval < 0 {
+		return fmt.Errorf("checkpoint interval must not be negative")
+	}
+
+	if c.Glossary.Chunk < 0 {
+		return fmt.Errorf("glossary chunk must not be negative")
+	}
+
+	if c.Glossary.Threshold < 0 || c.Glossary.Threshold > 1 {
+		return fmt.Errorf("glossary threshold must be between 0 and 1, got %v", c.Glossary.Threshold)
+	}
+
+	if c.TokenThold < 0 || c.TokenThold > 1 {
+		return fmt.Errorf("token threshold must be between 0 and 1, got %v", c.TokenThold)
+	}
+
+	if c.TokenSumThold < 0 || c.TokenSumThold > 1 {
+		return fmt.Errorf("token sum threshold must be between 0 and 1, got %v", c.TokenSumThold)
+	}
+
+	if c.AudioCtx > MaxAudioCtx {
+		return fmt.Errorf("audio context must not exceed %d, got %d", MaxAudioCtx, c.AudioCtx)
+	}
+
 	return nil
 }
 
+// VAD represents the configuration for the voice activity detection.
+type VAD struct {
+	Enabled    bool          // Enabled skips the silence before decoding.
+	Threshold  float64       // Threshold is the energy level in dBFS above which a frame is speech.
+	MinSpeech  time.Duration // MinSpeech is the minimum duration of a speech region.
+	MinSilence time.Duration // MinSilence is the minimum duration of silence between two speech regions.
+	Padding    time.Duration // Padding is added before and after every speech region.
+}
+
+// Clean represents the configuration for the hallucination and repetition suppression.
+type Clean struct {
+	Enabled     bool    // Enabled runs the post-processing over the segments.
+	MaxRepeat   int     // MaxRepeat is the number of consecutive repetitions allowed before a loop is removed.
+	MinProb     float64 // MinProb is the minimum average token probability of a segment.
+	MinEnergy   float64 // MinEnergy is the minimum energy level in dBFS of a segment.
+	PhrasesFile string  // PhrasesFile is a file with boilerplate hallucinations, one per line.
+	KeepRaw     bool    // KeepRaw also writes the uncleaned outputs.
+}
+
+// Checkpoint represents the configuration for saving and resuming an interrupted transcription.
+type Checkpoint struct {
+	Interval time.Duration // Interval between two checkpoints while decoding, 0 disables them.
+	Resume   bool          // Resume continues from the checkpoint of a previous run.
+}
+
+// Glossary represents the configuration for the domain vocabulary biasing.
+type Glossary struct {
+	File      string        // File has the product names and jargon, one term per line.
+	Chunk     time.Duration // Chunk is the audio decoded with the glossary in the prompt, 0 for a single pass.
+	Threshold float64       // Threshold is the minimum similarity of a near-miss spelling corrected to a term, 0 disables the correction.
+}
+
+// Rules represents the configuration for the text replacements applied before saving.
+type Rules struct {
+	File   string // File is a YAML file with the replacements of every language and of each language.
+	DryRun bool   // DryRun logs the changes without applying them.
+}
+
 // Webhook represents a webhook configuration with URL, Insecure and Headers.
 type Webhook struct {
-	URL      string
+	URL      string `sensitive:"url"`
 	Insecure bool
-	Headers  []string
+	Headers  []string `sensitive:"headers"`
 }
 
 // Setting is the configuration for whisper.
@@ -50,12 +193,88 @@ type Setting struct {
 	Whisper Whisper
 	Webhook Webhook
 	Youtube Youtube
+	Source  Source
+	S3      S3
+	Watch   Watch
+	Models  Models
+	Stream  Stream
+	Server  Server
+	Tracing Tracing
+	Cache   Cache
 }
 
 // Youtube represents the configuration for a YouTube video.
 type Youtube struct {
-	URL      string // URL is the YouTube video URL.
+	URL      string `sensitive:"url"` // URL is the YouTube video URL.
 	Insecure bool   // Insecure specifies whether to skip SSL verification.
 	Debug    bool   // Debug specifies whether to enable debug mode.
 	Retry    int    // Retry specifies the number of times to retry on failure.
 }
+
+// Source represents the configuration for downloading remote audio.
+type Source struct {
+	Insecure bool   // Insecure specifies whether to skip SSL verification.
+	Retry    int    // Retry specifies the number of times to retry on failure.
+	Checksum string // Checksum is the expected digest of the download, e.g. sha256:<hex>.
+}
+
+// S3 represents the configuration for an S3-compatible object storage.
+type S3 struct {
+	Endpoint  string // Endpoint is the storage endpoint, e.g. https://s3.amazonaws.com.
+	Region    string // Region is the bucket region.
+	AccessKey string `sensitive:"true"` // AccessKey is the access key ID.
+	SecretKey string `sensitive:"true"` // SecretKey is the secret access key.
+	PathStyle bool   // PathStyle forces path-style bucket addressing.
+	SSE       string // SSE is the server-side encryption for uploads, s3 or kms:<key-id>.
+}
+
+// Watch represents the configuration for the watch-folder daemon.
+type Watch struct {
+	Dirs         []string      // Dirs are the watched directories.
+	Extensions   []string      // Extensions are the audio file extensions to transcribe.
+	StableTime   time.Duration // StableTime is how long a file must stop growing before it is processed.
+	PollInterval time.Duration // PollInterval is how often the directories are rescanned, e.g. on NFS.
+}
+
+// Models represents the configuration for the local model cache.
+type Models struct {
+	Dir      string // Dir is the folder the models are downloaded to.
+	BaseURL  string `sensitive:"url"` // BaseURL is the location the model files are downloaded from.
+	Insecure bool   // Insecure specifies whether to skip SSL verification.
+	Retry    int    // Retry specifies the number of times to retry on failure.
+}
+
+// Cache represents the configuration for the result cache.
+type Cache struct {
+	Dir     string // Dir is the folder of the cached results, empty disables the cache.
+	MaxSize string // MaxSize is the size limit of the cache, e.g. 10GB, empty for no limit.
+}
+
+// Eval represents the text normalization applied before scoring a transcript.
+type Eval struct {
+	IgnoreCase         bool // IgnoreCase lowercases the texts.
+	IgnorePunctuation  bool // IgnorePunctuation strips the punctuation.
+	NumberWords        bool // NumberWords spells out the numbers, e.g. 42 becomes forty two.
+	ExpandContractions bool // ExpandContractions expands the English contractions, e.g. don't becomes do not.
+}
+
+// Stream represents the configuration for the real-time transcription.
+type Stream struct {
+	Listen string        // Listen is tcp://host:port or ws://host:port/path, standard input if empty.
+	Format string        // Format is the input encoding: s16le, f32le or any format ffmpeg decodes.
+	Step   time.Duration // Step is how often the pending audio is decoded.
+	Window time.Duration // Window is the longest audio decoded before a final segment is forced.
+}
+
+// Server represents the configuration for the HTTP API.
+type Server struct {
+	Addr        string        // Addr is the listen address, e.g. :8080.
+	AllowOrigin string        // AllowOrigin is the CORS origin allowed to call the API from a browser.
+	Workers     int           // Workers is the number of jobs processed at once.
+	JobTTL      time.Duration // JobTTL is how long a finished job is kept.
+}
+
+// Tracing represents the configuration for the OpenTelemetry traces.
+type Tracing struct {
+	Endpoint string `sensitive:"url"` // Endpoint is the OTLP/HTTP collector URL, tracing is disabled if empty.
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWhisper_Validate(t *testing.T) {
+	valid := func(fn func(c *Whisper)) *Whisper {
+		c := &Whisper{
+			Model:         "models/ggml-small.bin",
+			AudioPath:     "testdata/jfk.wav",
+			Threads:       4,
+			Language:      "auto",
+			TokenThold:    0.01,
+			TokenSumThold: 0.01,
+		}
+		if fn != nil {
+			fn(c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		cfg     *Whisper
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			cfg:  valid(nil),
+		},
+		{
+			name:    "missing audio path",
+			cfg:     valid(func(c *Whisper) { c.AudioPath = "" }),
+			wantErr: true,
+		},
+		{
+			name:    "missing model",
+			cfg:     valid(func(c *Whisper) { c.Model = "" }),
+			wantErr: true,
+		},
+		{
+			name: "english model with english",
+			cfg: valid(func(c *Whisper) {
+				c.Model = "models/ggml-base.en.bin"
+				c.Language = "en"
+			}),
+		},
+		{
+			name:    "unknown language",
+			cfg:     valid(func(c *Whisper) { c.Language = "eng" }),
+			wantErr: true,
+		},
+		{
+			name: "english model with translate",
+			cfg: valid(func(c *Whisper) {
+				c.Model = "models/ggml-base.en.bin"
+				c.Translate = true
+			}),
+			wantErr: true,
+		},
+		{
+			name: "quantized english model with german",
+			cfg: valid(func(c *Whisper) {
+				c.Model = "models/ggml-base.en-q5_1.bin"
+				c.Language = "de"
+			}),
+			wantErr: true,
+		},
+		{
+			name:    "zero threads",
+			cfg:     valid(func(c *Whisper) { c.Threads = 0 }),
+			wantErr: true,
+		},
+		{
+			name:    "beam size too large",
+			cfg:     valid(func(c *Whisper) { c.BeamSize = MaxBeamSize + 1 }),
+			wantErr: true,
+		},
+		{
+			name:    "negative offset",
+			cfg:     valid(func(c *Whisper) { c.Offset = -time.Second }),
+			wantErr: true,
+		},
+		{
+			name:    "token threshold out of range",
+			cfg:     valid(func(c *Whisper) { c.TokenThold = 1.5 }),
+			wantErr: true,
+		},
+		{
+			name:    "token sum threshold out of range",
+			cfg:     valid(func(c *Whisper) { c.TokenSumThold = -0.1 }),
+			wantErr: true,
+		},
+		{
+			name:    "audio context too large",
+			cfg:     valid(func(c *Whisper) { c.AudioCtx = MaxAudioCtx + 1 }),
+			wantErr: true,
+		},
+		{
+			name:    "negative vad padding",
+			cfg:     valid(func(c *Whisper) { c.VAD.Padding = -time.Millisecond }),
+			wantErr: true,
+		},
+		{
+			name:    "negative checkpoint interval",
+			cfg:     valid(func(c *Whisper) { c.Checkpoint.Interval = -time.Second }),
+			wantErr: true,
+		},
+		{
+			name:    "best of too large",
+			cfg:     valid(func(c *Whisper) { c.BestOf = MaxBeamSize + 1 }),
+			wantErr: true,
+		},
+		{
+			name:    "temperature out of range",
+			cfg:     valid(func(c *Whisper) { c.Temperature = 1.5 }),
+			wantErr: true,
+		},
+		{
+			name:    "negative temperature increment",
+			cfg:     valid(func(c *Whisper) { c.TemperatureInc = -0.2 }),
+			wantErr: true,
+		},
+		{
+			name:    "positive log probability threshold",
+			cfg:     valid(func(c *Whisper) { c.LogprobThold = 0.5 }),
+			wantErr: true,
+		},
+		{
+			name:    "no speech threshold out of range",
+			cfg:     valid(func(c *Whisper) { c.NoSpeechThold = 1.2 }),
+			wantErr: true,
+		},
+		{
+			name:    "glossary threshold out of range",
+			cfg:     valid(func(c *Whisper) { c.Glossary.Threshold = 1.5 }),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Whisper.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateLanguage(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{lang: "auto"},
+		{lang: "zh"},
+		{lang: "eng", want: `unknown language "eng", did you mean en (english)?`},
+		{lang: "German", want: `unknown language "German", did you mean de (german)?`},
+		{lang: "japanse", want: `unknown language "japanse", did you mean ja (japanese), jw (javanese)?`},
+		{lang: "xx", want: `unknown language "xx", use auto or an ISO 639-1 code such as en`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			got := ""
+			if err := ValidateLanguage(tt.lang); err != nil {
+				got = err.Error()
+			}
+			if got != tt.want {
+				t.Errorf("ValidateLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
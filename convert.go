@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/appleboy/go-whisper/sink"
+	"github.com/appleboy/go-whisper/whisper"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+)
+
+func convertCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "convert",
+		Usage:     "render json, srt, vtt or csv transcripts in other output formats without decoding",
+		ArgsUsage: "<transcript>...",
+		Action:    convert,
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:    "shift",
+				Usage:   "move the timestamps by this duration, negative to move them earlier",
+				EnvVars: []string{"PLUGIN_CONVERT_SHIFT", "INPUT_CONVERT_SHIFT"},
+			},
+		},
+	}
+}
+
+// convert reads the transcripts and saves them in every output format,
+// after the time shift, the post-processing and the segment length.
+func convert(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return errors.New("transcript is required, e.g. go-whisper --output-format vtt convert talk.srt")
+	}
+
+	cfg := newSetting(c)
+	out, err := sink.New(cfg.Whisper.OutputFolder, &cfg.S3, cfg.Source.Insecure)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range c.Args().Slice() {
+		segments, err := whisper.ReadTranscript(name)
+		if err != nil {
+			return err
+		}
+
+		job := cfg.Whisper
+		job.AudioPath = name
+		e, err := whisper.NewTranscript(
+			&job,
+			whisper.Shift(segments, c.Duration("shift")),
+			whisper.WithSink(out),
+			whisper.WithContext(c.Context),
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, format := range job.OutputFormat {
+			if err := e.Save(format); err != nil {
+				return err
+			}
+		}
+		log.Info().Str("transcript", name).Int("segments", len(segments)).Msg("transcript converted")
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/appleboy/go-whisper/whisper"
+
+	"github.com/urfave/cli/v2"
+)
+
+func detectCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "detect-language",
+		Usage:  "identify the spoken language without transcribing",
+		Action: detect,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:    "windows",
+				Usage:   "number of windows sampled evenly over the audio",
+				EnvVars: []string{"PLUGIN_DETECT_WINDOWS", "INPUT_DETECT_WINDOWS"},
+				Value:   1,
+			},
+			&cli.DurationFlag{
+				Name:    "window-duration",
+				Usage:   "duration of each window, up to 30s",
+				EnvVars: []string{"PLUGIN_DETECT_WINDOW_DURATION", "INPUT_DETECT_WINDOW_DURATION"},
+				Value:   30 * time.Second,
+			},
+			&cli.IntFlag{
+				Name:    "top",
+				Usage:   "number of languages to print",
+				EnvVars: []string{"PLUGIN_DETECT_TOP", "INPUT_DETECT_TOP"},
+				Value:   5,
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Usage:   "output format, support text, json",
+				EnvVars: []string{"PLUGIN_DETECT_FORMAT", "INPUT_DETECT_FORMAT"},
+				Value:   "text",
+			},
+		},
+	}
+}
+
+func detect(c *cli.Context) error {
+	cfg := newSetting(c)
+	setupDebug(&cfg)
+
+	if err := resolveModel(&cfg); err != nil {
+		return err
+	}
+
+	format := c.String("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+
+	cleanup, err := prepareInput(c.Context, &cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	langs, err := whisper.DetectLanguage(
+		&cfg.Whisper,
+		c.Int("windows"),
+		c.Duration("window-duration"),
+		c.Int("top"),
+	)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		return json.NewEncoder(os.Stdout).Encode(map[string]any{
+			"language":  langs[0].Language,
+			"languages": langs,
+		})
+	}
+
+	for _, lang := range langs {
+		fmt.Printf("%s\t%.4f\n", lang.Language, lang.Probability)
+	}
+
+	return nil
+}
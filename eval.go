@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/eval"
+
+	"github.com/urfave/cli/v2"
+)
+
+func evalCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "eval",
+		Usage:     "compute the word and character error rates against reference transcripts",
+		ArgsUsage: "<reference> <hypothesis>",
+		Action:    evaluate,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "ignore-case",
+				Usage:   "lowercase the texts before scoring",
+				EnvVars: []string{"PLUGIN_EVAL_IGNORE_CASE", "INPUT_EVAL_IGNORE_CASE"},
+				Value:   true,
+			},
+			&cli.BoolFlag{
+				Name:    "ignore-punctuation",
+				Usage:   "strip the punctuation before scoring",
+				EnvVars: []string{"PLUGIN_EVAL_IGNORE_PUNCTUATION", "INPUT_EVAL_IGNORE_PUNCTUATION"},
+				Value:   true,
+			},
+			&cli.BoolFlag{
+				Name:    "number-words",
+				Usage:   "spell out the numbers before scoring, e.g. 42 becomes forty two",
+				EnvVars: []string{"PLUGIN_EVAL_NUMBER_WORDS", "INPUT_EVAL_NUMBER_WORDS"},
+				Value:   true,
+			},
+			&cli.BoolFlag{
+				Name:    "expand-contractions",
+				Usage:   "expand the English contractions before scoring, e.g. don't becomes do not",
+				EnvVars: []string{"PLUGIN_EVAL_EXPAND_CONTRACTIONS", "INPUT_EVAL_EXPAND_CONTRACTIONS"},
+				Value:   true,
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Usage:   "output format, support text, json",
+				EnvVars: []string{"PLUGIN_EVAL_FORMAT", "INPUT_EVAL_FORMAT"},
+				Value:   "text",
+			},
+		},
+	}
+}
+
+// evaluate scores the hypothesis files or folder against the references.
+func evaluate(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return errors.New("reference and hypothesis are required, e.g. go-whisper eval testdata/jfk.txt jfk.srt")
+	}
+
+	format := c.String("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+
+	report, err := eval.Run(c.Args().Get(0), c.Args().Get(1), &config.Eval{
+		IgnoreCase:         c.Bool("ignore-case"),
+		IgnorePunctuation:  c.Bool("ignore-punctuation"),
+		NumberWords:        c.Bool("number-words"),
+		ExpandContractions: c.Bool("expand-contractions"),
+	})
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tWORDS\tSUB\tDEL\tINS\tWER\tCER")
+	for _, r := range append(report.Files, report.Total) {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.2f%%\t%.2f%%\n",
+			r.Name,
+			r.Words.Reference,
+			r.Words.Substitutions,
+			r.Words.Deletions,
+			r.Words.Insertions,
+			r.WER*100,
+			r.CER*100,
+		)
+	}
+
+	return w.Flush()
+}
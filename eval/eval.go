@@ -0,0 +1,265 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/transcript"
+)
+
+// Extensions are the transcript formats, in the order a hypothesis is
+// picked if several share the name of the reference.
+var Extensions = []string{".json", ".srt", ".vtt", ".csv", ".txt"}
+
+// Counts are the edit operations aligning the hypothesis to the reference.
+type Counts struct {
+	Reference     int `json:"reference"`
+	Hits          int `json:"hits"`
+	Substitutions int `json:"substitutions"`
+	Deletions     int `json:"deletions"`
+	Insertions    int `json:"insertions"`
+}
+
+// Rate returns the error rate, the edits divided by the reference length.
+func (c Counts) Rate() float64 {
+	if c.Reference == 0 {
+		if c.Insertions > 0 {
+			return 1
+		}
+		return 0
+	}
+	return float64(c.edits()) / float64(c.Reference)
+}
+
+func (c Counts) add(o Counts) Counts {
+	return Counts{
+		Reference:     c.Reference + o.Reference,
+		Hits:          c.Hits + o.Hits,
+		Substitutions: c.Substitutions + o.Substitutions,
+		Deletions:     c.Deletions + o.Deletions,
+		Insertions:    c.Insertions + o.Insertions,
+	}
+}
+
+// Result is the score of a hypothesis against its reference.
+type Result struct {
+	Name       string  `json:"name"`
+	Reference  string  `json:"reference,omitempty"`
+	Hypothesis string  `json:"hypothesis,omitempty"`
+	Words      Counts  `json:"words"`
+	Chars      Counts  `json:"chars"`
+	WER        float64 `json:"wer"`
+	CER        float64 `json:"cer"`
+}
+
+// Report holds the result of every pair and the overall result, where the
+// edits of all the pairs are added up before the rates are computed.
+type Report struct {
+	Files []Result `json:"files"`
+	Total Result   `json:"total"`
+}
+
+// Score compares the hypothesis text with the reference text.
+func Score(reference, hypothesis string, cfg *config.Eval) Result {
+	ref := Normalize(reference, cfg)
+	hyp := Normalize(hypothesis, cfg)
+
+	r := Result{
+		Words: Align(ref, hyp),
+		Chars: Align(chars(ref), chars(hyp)),
+	}
+	r.WER = r.Words.Rate()
+	r.CER = r.Chars.Rate()
+	return r
+}
+
+// chars splits the words into characters, the spaces between the words included.
+func chars(words []string) []string {
+	var out []string
+	for _, r := range strings.Join(words, " ") {
+		out = append(out, string(r))
+	}
+	return out
+}
+
+// Align counts the edits of the minimum edit distance between the
+// reference and the hypothesis tokens. Only two rows of the distance matrix
+// are kept, each cell carrying the counts of its cheapest path.
+func Align(ref, hyp []string) Counts {
+	// prev[j] aligns ref[:i-1] and hyp[:j], cur[j] aligns ref[:i] and hyp[:j]
+	prev := make([]Counts, len(hyp)+1)
+	cur := make([]Counts, len(hyp)+1)
+	for j := 1; j <= len(hyp); j++ {
+		prev[j] = Counts{Insertions: j}
+	}
+	for i := 1; i <= len(ref); i++ {
+		cur[0] = Counts{Deletions: i}
+		for j := 1; j <= len(hyp); j++ {
+			// a hit or substitution is preferred over a deletion, then an insertion
+			best := prev[j-1]
+			if ref[i-1] == hyp[j-1] {
+				best.Hits++
+			} else {
+				best.Substitutions++
+			}
+			if del := prev[j]; del.edits()+1 < best.edits() {
+				best = del
+				best.Deletions++
+			}
+			if ins := cur[j-1]; ins.edits()+1 < best.edits() {
+				best = ins
+				best.Insertions++
+			}
+			cur[j] = best
+		}
+		prev, cur = cur, prev
+	}
+
+	c := prev[len(hyp)]
+	c.Reference = len(ref)
+	return c
+}
+
+// edits returns the edit distance of the counts.
+func (c Counts) edits() int {
+	return c.Substitutions + c.Deletions + c.Insertions
+}
+
+// ReadText returns the text of a transcript, the timestamps of the json,
+// srt, vtt and csv formats are dropped.
+func ReadText(name string) (string, error) {
+	if strings.EqualFold(filepath.Ext(name), ".txt") {
+		data, err := os.ReadFile(name)
+		return string(data), err
+	}
+
+	segments, err := transcript.Read(name)
+	if err != nil {
+		return "", err
+	}
+	texts := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		texts = append(texts, strings.TrimSpace(segment.Text))
+	}
+	return strings.Join(texts, " "), nil
+}
+
+// ScoreFiles compares a hypothesis file with a reference file.
+func ScoreFiles(reference, hypothesis string, cfg *config.Eval) (Result, error) {
+	ref, err := ReadText(reference)
+	if err != nil {
+		return Result{}, err
+	}
+	hyp, err := ReadText(hypothesis)
+	if err != nil {
+		return Result{}, err
+	}
+
+	r := Score(ref, hyp, cfg)
+	r.Name = strings.TrimSuffix(filepath.Base(reference), filepath.Ext(reference))
+	r.Reference = reference
+	r.Hypothesis = hypothesis
+	return r, nil
+}
+
+// Run scores a hypothesis against a reference, both files or both folders.
+// In folders, every reference is paired with the hypothesis of the same name.
+func Run(reference, hypothesis string, cfg *config.Eval) (*Report, error) {
+	pairs, err := pair(reference, hypothesis)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Total: Result{Name: "total"}}
+	for _, p := range pairs {
+		r, err := ScoreFiles(p[0], p[1], cfg)
+		if err != nil {
+			return nil, err
+		}
+		report.Files = append(report.Files, r)
+		report.Total.Words = report.Total.Words.add(r.Words)
+		report.Total.Chars = report.Total.Chars.add(r.Chars)
+	}
+	report.Total.WER = report.Total.Words.Rate()
+	report.Total.CER = report.Total.Chars.Rate()
+
+	return report, nil
+}
+
+// pair returns the reference and hypothesis files to compare.
+func pair(reference, hypothesis string) ([][2]string, error) {
+	refInfo, err := os.Stat(reference)
+	if err != nil {
+		return nil, err
+	}
+	hypInfo, err := os.Stat(hypothesis)
+	if err != nil {
+		return nil, err
+	}
+	if refInfo.IsDir() != hypInfo.IsDir() {
+		return nil, errors.New("reference and hypothesis must both be files or both be folders")
+	}
+	if !refInfo.IsDir() {
+		return [][2]string{{reference, hypothesis}}, nil
+	}
+
+	entries, err := os.ReadDir(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		pairs   [][2]string
+		missing []string
+	)
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if entry.IsDir() || !supported(ext) || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		hyp := find(hypothesis, name)
+		if hyp == "" {
+			missing = append(missing, entry.Name())
+			continue
+		}
+		pairs = append(pairs, [2]string{filepath.Join(reference, entry.Name()), hyp})
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no hypothesis in %s for %s", hypothesis, strings.Join(missing, ", "))
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no reference transcript in %s", reference)
+	}
+
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+	return pairs, nil
+}
+
+// find returns the transcript of the folder with the given name.
+func find(dir, name string) string {
+	for _, ext := range Extensions {
+		p := filepath.Join(dir, name+ext)
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return p
+		}
+	}
+	return ""
+}
+
+func supported(ext string) bool {
+	for _, e := range Extensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package eval
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/appleboy/go-whisper/config"
+)
+
+var all = &config.Eval{
+	IgnoreCase:         true,
+	IgnorePunctuation:  true,
+	NumberWords:        true,
+	ExpandContractions: true,
+}
+
+func TestAlign(t *testing.T) {
+	tests := []struct {
+		name string
+		ref  string
+		hyp  string
+		want Counts
+	}{
+		{
+			name: "identical",
+			ref:  "ask not what your country",
+			hyp:  "ask not what your country",
+			want: Counts{Reference: 5, Hits: 5},
+		},
+		{
+			name: "substitution",
+			ref:  "ask not what your country",
+			hyp:  "ask not what you country",
+			want: Counts{Reference: 5, Hits: 4, Substitutions: 1},
+		},
+		{
+			name: "deletion and insertion",
+			ref:  "ask not what your country",
+			hyp:  "not what your great country",
+			want: Counts{Reference: 5, Hits: 4, Deletions: 1, Insertions: 1},
+		},
+		{
+			name: "empty hypothesis",
+			ref:  "ask not",
+			hyp:  "",
+			want: Counts{Reference: 2, Deletions: 2},
+		},
+		{
+			name: "empty reference",
+			ref:  "",
+			hyp:  "ask",
+			want: Counts{Insertions: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Align(strings.Fields(tt.ref), strings.Fields(tt.hyp)); got != tt.want {
+				t.Errorf("Align() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		cfg  *config.Eval
+		want []string
+	}{
+		{
+			name: "unchanged",
+			text: "Don't pay $1,500.",
+			cfg:  &config.Eval{},
+			want: []string{"Don't", "pay", "$1,500."},
+		},
+		{
+			name: "case and punctuation",
+			text: "And so, my fellow Americans: ask not!",
+			cfg:  &config.Eval{IgnoreCase: true, IgnorePunctuation: true},
+			want: []string{"and", "so", "my", "fellow", "americans", "ask", "not"},
+		},
+		{
+			name: "contractions",
+			text: "Don’t worry, it's what we'll do. I CAN'T.",
+			cfg:  &config.Eval{ExpandContractions: true},
+			want: []string{"Do", "not", "worry,", "it", "is", "what", "we", "will", "do.", "I", "CAN", "NOT."},
+		},
+		{
+			name: "number words",
+			text: "Pay $1,500 for 3.14 acres in 2024.",
+			cfg:  all,
+			want: strings.Fields("pay one thousand five hundred for three point one four acres in two thousand twenty four"),
+		},
+		{
+			name: "hyphens",
+			text: "a well-known state-of-the-art model",
+			cfg:  all,
+			want: strings.Fields("a well known state of the art model"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.text, tt.cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpellInt(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "0", want: "zero"},
+		{in: "13", want: "thirteen"},
+		{in: "40", want: "forty"},
+		{in: "105", want: "one hundred five"},
+		{in: "1000000", want: "one million"},
+		{in: "2001", want: "two thousand one"},
+		{in: "12345678901234567890", want: "one two three four five six seven eight nine zero one two three four five six seven eight nine zero"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := spellInt(tt.in); got != tt.want {
+				t.Errorf("spellInt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	ref, hyp := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		filepath.Join(ref, "jfk.txt"): "And so my fellow Americans, ask not what your country can do for you.",
+		filepath.Join(ref, "one.txt"): "one two three four",
+		filepath.Join(hyp, "jfk.srt"): "1\n00:00:00,000 --> 00:00:04,000\n And so my fellow Americans,\n\n2\n00:00:04,000 --> 00:00:08,000\n ask not what your country can do for you.\n",
+		filepath.Join(hyp, "one.txt"): "one 2 three",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := Run(ref, hyp, all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Files) != 2 {
+		t.Fatalf("files = %d, want 2", len(report.Files))
+	}
+	if r := report.Files[0]; r.Name != "jfk" || r.WER != 0 {
+		t.Errorf("jfk = %+v, want a perfect score", r)
+	}
+	if r := report.Files[1]; r.Name != "one" || r.Words.Deletions != 1 || r.WER != 0.25 {
+		t.Errorf("one = %+v, want one deletion", r)
+	}
+	want := Counts{Reference: 18, Hits: 17, Deletions: 1}
+	if report.Total.Words != want {
+		t.Errorf("total = %+v, want %+v", report.Total.Words, want)
+	}
+
+	// a reference without hypothesis
+	if err := os.WriteFile(filepath.Join(ref, "two.txt"), []byte("two"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Run(ref, hyp, all); err == nil {
+		t.Error("Run() error = nil, want the missing hypothesis")
+	}
+}
+
+func TestScoreFiles_Fixture(t *testing.T) {
+	hyp := filepath.Join(t.TempDir(), "jfk.txt")
+	text := " And so my fellow Americans, ask not what your country can do for you, ask what you can do for your country."
+	if err := os.WriteFile(hyp, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := ScoreFiles("../testdata/jfk.txt", hyp, all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.WER != 0 || r.CER != 0 || r.Words.Reference != 22 {
+		t.Errorf("ScoreFiles() = %+v, want a perfect score over 22 words", r)
+	}
+}
@@ -0,0 +1,234 @@
+package eval
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/appleboy/go-whisper/config"
+)
+
+// contractions are the English contractions expanded before scoring. The
+// possessive 's is ambiguous, so it's only expanded after the pronouns.
+var contractions = map[string]string{
+	"can't":   "can not",
+	"cannot":  "can not",
+	"won't":   "will not",
+	"shan't":  "shall not",
+	"ain't":   "is not",
+	"let's":   "let us",
+	"it's":    "it is",
+	"that's":  "that is",
+	"what's":  "what is",
+	"there's": "there is",
+	"here's":  "here is",
+	"he's":    "he is",
+	"she's":   "she is",
+	"who's":   "who is",
+	"where's": "where is",
+}
+
+// suffixes are the contracted verbs, e.g. they're or we'll.
+var suffixes = []struct{ suffix, expansion string }{
+	{"n't", " not"},
+	{"'re", " are"},
+	{"'ve", " have"},
+	{"'ll", " will"},
+	{"'m", " am"},
+	{"'d", " would"},
+}
+
+// Normalize returns the words of the text after the normalization.
+func Normalize(text string, cfg *config.Eval) []string {
+	// typographic apostrophes, e.g. don’t
+	text = strings.ReplaceAll(text, "’", "'")
+	if cfg.IgnoreCase {
+		text = strings.ToLower(text)
+	}
+
+	var words []string
+	for _, word := range strings.Fields(text) {
+		if cfg.ExpandContractions {
+			word = expand(word)
+		}
+		if cfg.IgnorePunctuation {
+			word = stripPunctuation(word)
+		}
+		if cfg.NumberWords {
+			word = spellNumbers(word)
+		}
+		words = append(words, strings.Fields(word)...)
+	}
+	return words
+}
+
+// expand expands a contraction, keeping the surrounding punctuation.
+func expand(word string) string {
+	start := strings.IndexFunc(word, isWordRune)
+	end := strings.LastIndexFunc(word, isWordRune)
+	if start < 0 {
+		return word
+	}
+	prefix, core, suffix := word[:start], word[start:end+1], word[end+1:]
+
+	lower := strings.ToLower(core)
+	if expansion, ok := contractions[lower]; ok {
+		return prefix + matchCase(core, expansion) + suffix
+	}
+	for _, s := range suffixes {
+		if len(lower) > len(s.suffix) && strings.HasSuffix(lower, s.suffix) {
+			stem := core[:len(core)-len(s.suffix)]
+			return prefix + stem + matchCase(core, s.expansion) + suffix
+		}
+	}
+	return word
+}
+
+// matchCase uppercases the expansion if the contraction is uppercase.
+func matchCase(word, expansion string) string {
+	if word == strings.ToUpper(word) && word != strings.ToLower(word) {
+		return strings.ToUpper(expansion)
+	}
+	if r := []rune(word); len(r) > 0 && unicode.IsUpper(r[0]) {
+		e := []rune(expansion)
+		e[0] = unicode.ToUpper(e[0])
+		return string(e)
+	}
+	return expansion
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// stripPunctuation removes the punctuation and symbols, keeping the decimal
+// point and the thousands separator of the numbers and the apostrophes
+// inside the words.
+func stripPunctuation(word string) string {
+	r := []rune(word)
+	var b strings.Builder
+	for i, c := range r {
+		if isWordRune(c) {
+			b.WriteRune(c)
+			continue
+		}
+		inside := i > 0 && i < len(r)-1 && isWordRune(r[i-1]) && isWordRune(r[i+1])
+		switch {
+		case inside && (c == '.' || c == ',') && unicode.IsDigit(r[i-1]) && unicode.IsDigit(r[i+1]):
+			b.WriteRune(c)
+		case inside && c == '\'':
+			b.WriteRune(c)
+		case inside && c == '-':
+			b.WriteRune(' ')
+		}
+	}
+	return b.String()
+}
+
+// spellNumbers spells out the integers and decimals of the word,
+// e.g. 1,500 becomes one thousand five hundred.
+func spellNumbers(word string) string {
+	var (
+		b     strings.Builder
+		digit strings.Builder
+	)
+	flush := func() {
+		if digit.Len() == 0 {
+			return
+		}
+		b.WriteString(" " + spellDecimal(digit.String()) + " ")
+		digit.Reset()
+	}
+
+	r := []rune(word)
+	for i, c := range r {
+		switch {
+		case isDigit(c):
+			digit.WriteRune(c)
+		case (c == '.' || c == ',') && digit.Len() > 0 && i+1 < len(r) && isDigit(r[i+1]):
+			digit.WriteRune(c)
+		default:
+			flush()
+			b.WriteRune(c)
+		}
+	}
+	flush()
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// spellDecimal spells a number such as 1,500 or 3.14.
+func spellDecimal(s string) string {
+	// a comma is a thousands separator, a point starts the fraction
+	s = strings.ReplaceAll(s, ",", "")
+	whole, fraction, _ := strings.Cut(s, ".")
+
+	words := []string{spellInt(whole)}
+	if fraction != "" {
+		words = append(words, "point")
+		for _, d := range fraction {
+			words = append(words, ones[d-'0'])
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+var (
+	ones = []string{
+		"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+		"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen",
+	}
+	tens   = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	scales = []string{"", "thousand", "million", "billion", "trillion"}
+)
+
+// spellInt spells an integer, the numbers too large to spell are read digit by digit.
+func spellInt(s string) string {
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || len(s) > 15 {
+		words := make([]string, 0, len(s))
+		for _, d := range s {
+			words = append(words, ones[d-'0'])
+		}
+		return strings.Join(words, " ")
+	}
+	if n == 0 {
+		return ones[0]
+	}
+
+	var groups []string
+	for scale := 0; n > 0; scale++ {
+		if group := n % 1000; group > 0 {
+			words := spellHundreds(int(group))
+			if scales[scale] != "" {
+				words += " " + scales[scale]
+			}
+			groups = append([]string{words}, groups...)
+		}
+		n /= 1000
+	}
+	return strings.Join(groups, " ")
+}
+
+// spellHundreds spells a number between 1 and 999.
+func spellHundreds(n int) string {
+	var words []string
+	if n >= 100 {
+		words = append(words, ones[n/100], "hundred")
+		n %= 100
+	}
+	switch {
+	case n >= 20:
+		words = append(words, tens[n/10])
+		if n%10 > 0 {
+			words = append(words, ones[n%10])
+		}
+	case n > 0:
+		words = append(words, ones[n])
+	}
+	return strings.Join(words, " ")
+}
@@ -1,22 +1,36 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/appleboy/go-whisper/cache"
 	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/metrics"
+	"github.com/appleboy/go-whisper/model"
+	"github.com/appleboy/go-whisper/progress"
+	"github.com/appleboy/go-whisper/sink"
+	"github.com/appleboy/go-whisper/source"
+	"github.com/appleboy/go-whisper/tracing"
 	"github.com/appleboy/go-whisper/webhook"
 	"github.com/appleboy/go-whisper/whisper"
 	"github.com/appleboy/go-whisper/youtube"
 
-	"github.com/davecgh/go-spew/spew"
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/mattn/go-isatty"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Version set at compile-time
@@ -56,26 +70,91 @@ func main() {
 		},
 	}
 	app.Action = run
+	app.Before = func(c *cli.Context) error {
+		if err := loadConfig(c); err != nil {
+			return err
+		}
+		if err := setupLog(c); err != nil {
+			return err
+		}
+		if err := setupTracing(c); err != nil {
+			return err
+		}
+		return serveMetrics(c.String("metrics-addr"))
+	}
+	app.After = func(c *cli.Context) error {
+		if path := c.String("metrics-file"); path != "" {
+			if err := metrics.WriteFile(path); err != nil {
+				log.Error().Err(err).Str("path", path).Msg("write metrics file error")
+			}
+		}
+		return shutdownTracing(context.Background())
+	}
 	app.Version = Version
+	app.Commands = []*cli.Command{
+		watchCommand(),
+		detectCommand(),
+		modelsCommand(),
+		cacheCommand(),
+		convertCommand(),
+		evalCommand(),
+		configCommand(),
+		runCommand(),
+		streamCommand(),
+		serverCommand(),
+	}
 	app.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config",
+			Usage:   "yaml or toml configuration file, overridden by flags and env",
+			EnvVars: []string{"PLUGIN_CONFIG", "INPUT_CONFIG"},
+		},
+		&cli.StringFlag{
+			Name:    "profile",
+			Usage:   "named profile of the configuration file",
+			EnvVars: []string{"PLUGIN_PROFILE", "INPUT_PROFILE"},
+		},
 		&cli.StringFlag{
 			Name:    "model",
-			Usage:   "Model is the interface to a whisper model",
+			Usage:   "model file or the name of a downloaded model, e.g. small",
 			EnvVars: []string{"PLUGIN_MODEL", "INPUT_MODEL"},
 		},
+		&cli.StringFlag{
+			Name:    "model-dir",
+			Usage:   "folder the models are downloaded to",
+			EnvVars: []string{"PLUGIN_MODEL_DIR", "INPUT_MODEL_DIR"},
+			Value:   model.DefaultDir(),
+		},
+		&cli.StringFlag{
+			Name:    "model-base-url",
+			Usage:   "location the models are downloaded from",
+			EnvVars: []string{"PLUGIN_MODEL_BASE_URL", "INPUT_MODEL_BASE_URL"},
+			Value:   model.DefaultBaseURL,
+		},
+		&cli.StringFlag{
+			Name:    "cache-dir",
+			Usage:   "folder of the result cache, reused for the same audio and decoding options",
+			EnvVars: []string{"PLUGIN_CACHE_DIR", "INPUT_CACHE_DIR"},
+		},
+		&cli.StringFlag{
+			Name:    "cache-max-size",
+			Usage:   "size limit of the result cache, the least recently used results are evicted",
+			EnvVars: []string{"PLUGIN_CACHE_MAX_SIZE", "INPUT_CACHE_MAX_SIZE"},
+			Value:   "10GB",
+		},
 		&cli.StringFlag{
 			Name:    "audio-path",
-			Usage:   "audio path",
+			Usage:   "audio path, http(s) url, s3://bucket/key or - for stdin",
 			EnvVars: []string{"PLUGIN_AUDIO_PATH", "INPUT_AUDIO_PATH"},
 		},
 		&cli.StringFlag{
 			Name:    "output-folder",
-			Usage:   "output folder",
+			Usage:   "output folder, s3://bucket/prefix or - for stdout",
 			EnvVars: []string{"PLUGIN_OUTPUT_FOLDER", "INPUT_OUTPUT_FOLDER"},
 		},
 		&cli.StringSliceFlag{
 			Name:    "output-format",
-			Usage:   "output format, support txt, srt, csv",
+			Usage:   "output format, support txt, srt, vtt, csv, json",
 			EnvVars: []string{"PLUGIN_OUTPUT_FORMAT", "INPUT_OUTPUT_FORMAT"},
 			Value:   cli.NewStringSlice("txt"),
 		},
@@ -101,6 +180,33 @@ func main() {
 			Usage:   "enable debug mode",
 			EnvVars: []string{"PLUGIN_DEBUG", "INPUT_DEBUG"},
 		},
+		&cli.StringFlag{
+			Name:    "log-format",
+			Usage:   "log format: console or json, json also carries the whisper.cpp logs",
+			EnvVars: []string{"PLUGIN_LOG_FORMAT", "INPUT_LOG_FORMAT"},
+			Value:   "console",
+		},
+		&cli.StringFlag{
+			Name:    "log-level",
+			Usage:   "log level: trace, debug, info, warn or error",
+			EnvVars: []string{"PLUGIN_LOG_LEVEL", "INPUT_LOG_LEVEL"},
+			Value:   "info",
+		},
+		&cli.StringFlag{
+			Name:    "metrics-addr",
+			Usage:   "serve prometheus metrics on this address, e.g. :9090",
+			EnvVars: []string{"PLUGIN_METRICS_ADDR", "INPUT_METRICS_ADDR"},
+		},
+		&cli.StringFlag{
+			Name:    "metrics-file",
+			Usage:   "write the prometheus metrics to this file on exit, e.g. for the node exporter textfile collector",
+			EnvVars: []string{"PLUGIN_METRICS_FILE", "INPUT_METRICS_FILE"},
+		},
+		&cli.StringFlag{
+			Name:    "otlp-endpoint",
+			Usage:   "export opentelemetry traces to this otlp/http collector, e.g. http://localhost:4318",
+			EnvVars: []string{"PLUGIN_OTLP_ENDPOINT", "INPUT_OTLP_ENDPOINT"},
+		},
 		&cli.BoolFlag{
 			Name:    "speedup",
 			Usage:   "speed up audio by x2 (reduced accuracy)",
@@ -144,13 +250,15 @@ func main() {
 		},
 		&cli.BoolFlag{
 			Name:    "youtube-insecure",
-			Usage:   "youtube insecure",
-			EnvVars: []string{"PLUGIN_YOUTUBE_INSECURE", "INPUT_YOUTUBE_INSECURE"},
+			Aliases: []string{"download-insecure"},
+			Usage:   "skip ssl verification when downloading youtube videos, remote audio or models",
+			EnvVars: []string{"PLUGIN_YOUTUBE_INSECURE", "INPUT_YOUTUBE_INSECURE", "PLUGIN_DOWNLOAD_INSECURE", "INPUT_DOWNLOAD_INSECURE"},
 		},
 		&cli.IntFlag{
 			Name:    "youtube-retry-count",
-			Usage:   "youtube retry count",
-			EnvVars: []string{"PLUGIN_YOUTUBE_RETRY_COUNT", "INPUT_YOUTUBE_RETRY_COUNT"},
+			Aliases: []string{"download-retry-count"},
+			Usage:   "retry count when downloading youtube videos, remote audio or models",
+			EnvVars: []string{"PLUGIN_YOUTUBE_RETRY_COUNT", "INPUT_YOUTUBE_RETRY_COUNT", "PLUGIN_DOWNLOAD_RETRY_COUNT", "INPUT_DOWNLOAD_RETRY_COUNT"},
 			Value:   20,
 		},
 		&cli.StringFlag{
@@ -176,6 +284,217 @@ func main() {
 			EnvVars: []string{"PLUGIN_ENTROPY_THOLD", "INPUT_ENTROPY_THOLD"},
 			Value:   2.4,
 		},
+		&cli.Float64Flag{
+			Name:    "temperature",
+			Usage:   "initial sampling temperature between 0 and 1, 0 decodes greedily",
+			EnvVars: []string{"PLUGIN_TEMPERATURE", "INPUT_TEMPERATURE"},
+		},
+		&cli.Float64Flag{
+			Name:    "temperature-inc",
+			Usage:   "temperature increase when a decoding fails the thresholds, 0 disables the fallback",
+			EnvVars: []string{"PLUGIN_TEMPERATURE_INC", "INPUT_TEMPERATURE_INC"},
+			Value:   0.2,
+		},
+		&cli.UintFlag{
+			Name:    "best-of",
+			Usage:   "number of candidates sampled when the temperature is above 0",
+			EnvVars: []string{"PLUGIN_BEST_OF", "INPUT_BEST_OF"},
+			Value:   5,
+		},
+		&cli.Float64Flag{
+			Name:    "logprob-thold",
+			Usage:   "average log probability threshold for decoder fail",
+			EnvVars: []string{"PLUGIN_LOGPROB_THOLD", "INPUT_LOGPROB_THOLD"},
+			Value:   -1,
+		},
+		&cli.Float64Flag{
+			Name:    "no-speech-thold",
+			Usage:   "no speech probability threshold above which a segment is silence",
+			EnvVars: []string{"PLUGIN_NO_SPEECH_THOLD", "INPUT_NO_SPEECH_THOLD"},
+			Value:   0.6,
+		},
+		&cli.DurationFlag{
+			Name:    "offset",
+			Usage:   "start transcribing at this offset of the audio",
+			EnvVars: []string{"PLUGIN_OFFSET", "INPUT_OFFSET"},
+		},
+		&cli.DurationFlag{
+			Name:    "duration",
+			Usage:   "duration of audio to transcribe, 0 for the rest of the audio",
+			EnvVars: []string{"PLUGIN_DURATION", "INPUT_DURATION"},
+		},
+		&cli.DurationFlag{
+			Name:    "checkpoint-interval",
+			Usage:   "save the decoded segments to a checkpoint at this interval, 0 to disable",
+			EnvVars: []string{"PLUGIN_CHECKPOINT_INTERVAL", "INPUT_CHECKPOINT_INTERVAL"},
+			Value:   time.Minute,
+		},
+		&cli.BoolFlag{
+			Name:    "resume",
+			Usage:   "resume decoding from the checkpoint of an interrupted run",
+			EnvVars: []string{"PLUGIN_RESUME", "INPUT_RESUME"},
+		},
+		&cli.BoolFlag{
+			Name:    "split-on-word",
+			Usage:   "split segments on word rather than on token",
+			EnvVars: []string{"PLUGIN_SPLIT_ON_WORD", "INPUT_SPLIT_ON_WORD"},
+		},
+		&cli.UintFlag{
+			Name:    "max-len",
+			Usage:   "maximum segment length in characters, 0 for no limit",
+			EnvVars: []string{"PLUGIN_MAX_LEN", "INPUT_MAX_LEN"},
+		},
+		&cli.UintFlag{
+			Name:    "max-tokens",
+			Usage:   "maximum number of tokens per segment, 0 for no limit",
+			EnvVars: []string{"PLUGIN_MAX_TOKENS", "INPUT_MAX_TOKENS"},
+		},
+		&cli.BoolFlag{
+			Name:    "token-timestamps",
+			Usage:   "compute token-level timestamps",
+			EnvVars: []string{"PLUGIN_TOKEN_TIMESTAMPS", "INPUT_TOKEN_TIMESTAMPS"},
+		},
+		&cli.Float64Flag{
+			Name:    "token-thold",
+			Usage:   "timestamp token probability threshold",
+			EnvVars: []string{"PLUGIN_TOKEN_THOLD", "INPUT_TOKEN_THOLD"},
+			Value:   0.01,
+		},
+		&cli.Float64Flag{
+			Name:    "token-sum-thold",
+			Usage:   "timestamp token sum probability threshold",
+			EnvVars: []string{"PLUGIN_TOKEN_SUM_THOLD", "INPUT_TOKEN_SUM_THOLD"},
+			Value:   0.01,
+		},
+		&cli.UintFlag{
+			Name:    "audio-ctx",
+			Usage:   "audio context size, 0 for the full context",
+			EnvVars: []string{"PLUGIN_AUDIO_CTX", "INPUT_AUDIO_CTX"},
+		},
+		&cli.BoolFlag{
+			Name:    "vad",
+			Usage:   "skip silence with voice activity detection before decoding",
+			EnvVars: []string{"PLUGIN_VAD", "INPUT_VAD"},
+		},
+		&cli.Float64Flag{
+			Name:    "vad-threshold",
+			Usage:   "energy threshold in dBFS above which audio is speech",
+			EnvVars: []string{"PLUGIN_VAD_THRESHOLD", "INPUT_VAD_THRESHOLD"},
+			Value:   -45,
+		},
+		&cli.DurationFlag{
+			Name:    "vad-min-speech",
+			Usage:   "minimum duration of a speech region",
+			EnvVars: []string{"PLUGIN_VAD_MIN_SPEECH", "INPUT_VAD_MIN_SPEECH"},
+			Value:   250 * time.Millisecond,
+		},
+		&cli.DurationFlag{
+			Name:    "vad-min-silence",
+			Usage:   "minimum duration of silence between two speech regions",
+			EnvVars: []string{"PLUGIN_VAD_MIN_SILENCE", "INPUT_VAD_MIN_SILENCE"},
+			Value:   time.Second,
+		},
+		&cli.DurationFlag{
+			Name:    "vad-padding",
+			Usage:   "padding added before and after every speech region",
+			EnvVars: []string{"PLUGIN_VAD_PADDING", "INPUT_VAD_PADDING"},
+			Value:   200 * time.Millisecond,
+		},
+		&cli.BoolFlag{
+			Name:    "clean",
+			Usage:   "remove hallucinations and repetition loops from the segments",
+			EnvVars: []string{"PLUGIN_CLEAN", "INPUT_CLEAN"},
+		},
+		&cli.IntFlag{
+			Name:    "clean-max-repeat",
+			Usage:   "consecutive repetitions allowed before a loop is removed",
+			EnvVars: []string{"PLUGIN_CLEAN_MAX_REPEAT", "INPUT_CLEAN_MAX_REPEAT"},
+			Value:   2,
+		},
+		&cli.Float64Flag{
+			Name:    "clean-min-prob",
+			Usage:   "minimum average token probability of a segment",
+			EnvVars: []string{"PLUGIN_CLEAN_MIN_PROB", "INPUT_CLEAN_MIN_PROB"},
+			Value:   0.3,
+		},
+		&cli.Float64Flag{
+			Name:    "clean-min-energy",
+			Usage:   "minimum energy level in dBFS of a segment",
+			EnvVars: []string{"PLUGIN_CLEAN_MIN_ENERGY", "INPUT_CLEAN_MIN_ENERGY"},
+			Value:   -60,
+		},
+		&cli.StringFlag{
+			Name:    "clean-phrases",
+			Usage:   "file with boilerplate hallucinations to remove, one per line",
+			EnvVars: []string{"PLUGIN_CLEAN_PHRASES", "INPUT_CLEAN_PHRASES"},
+		},
+		&cli.BoolFlag{
+			Name:    "keep-raw",
+			Usage:   "also save the uncleaned outputs as <name>.raw.<format>",
+			EnvVars: []string{"PLUGIN_KEEP_RAW", "INPUT_KEEP_RAW"},
+		},
+		&cli.StringFlag{
+			Name:    "glossary",
+			Usage:   "file with product names and jargon, one term per line, put in the prompt and corrected in the transcript",
+			EnvVars: []string{"PLUGIN_GLOSSARY", "INPUT_GLOSSARY"},
+		},
+		&cli.DurationFlag{
+			Name:    "glossary-chunk",
+			Usage:   "length of the audio decoded with the glossary in the prompt, 0 for a single pass",
+			EnvVars: []string{"PLUGIN_GLOSSARY_CHUNK", "INPUT_GLOSSARY_CHUNK"},
+			Value:   30 * time.Second,
+		},
+		&cli.Float64Flag{
+			Name:    "glossary-threshold",
+			Usage:   "minimum similarity (0-1) of a spelling corrected to a glossary term, 0 to disable the correction",
+			EnvVars: []string{"PLUGIN_GLOSSARY_THRESHOLD", "INPUT_GLOSSARY_THRESHOLD"},
+			Value:   0.8,
+		},
+		&cli.StringFlag{
+			Name:    "rules",
+			Usage:   "yaml file with ordered text replacements applied to the segments before saving",
+			EnvVars: []string{"PLUGIN_RULES", "INPUT_RULES"},
+		},
+		&cli.BoolFlag{
+			Name:    "rules-dry-run",
+			Usage:   "log the replacements of the rules without applying them",
+			EnvVars: []string{"PLUGIN_RULES_DRY_RUN", "INPUT_RULES_DRY_RUN"},
+		},
+		&cli.StringFlag{
+			Name:    "download-checksum",
+			Usage:   "expected checksum of remote audio, e.g. sha256:<hex>",
+			EnvVars: []string{"PLUGIN_DOWNLOAD_CHECKSUM", "INPUT_DOWNLOAD_CHECKSUM"},
+		},
+		&cli.StringFlag{
+			Name:    "s3-endpoint",
+			Usage:   "s3-compatible endpoint",
+			EnvVars: []string{"PLUGIN_S3_ENDPOINT", "INPUT_S3_ENDPOINT"},
+		},
+		&cli.StringFlag{
+			Name:    "s3-region",
+			Usage:   "s3 region",
+			EnvVars: []string{"PLUGIN_S3_REGION", "INPUT_S3_REGION"},
+		},
+		&cli.StringFlag{
+			Name:    "s3-access-key",
+			Usage:   "s3 access key",
+			EnvVars: []string{"PLUGIN_S3_ACCESS_KEY", "INPUT_S3_ACCESS_KEY"},
+		},
+		&cli.StringFlag{
+			Name:    "s3-secret-key",
+			Usage:   "s3 secret key",
+			EnvVars: []string{"PLUGIN_S3_SECRET_KEY", "INPUT_S3_SECRET_KEY"},
+		},
+		&cli.BoolFlag{
+			Name:    "s3-path-style",
+			Usage:   "use path-style s3 bucket addressing",
+			EnvVars: []string{"PLUGIN_S3_PATH_STYLE", "INPUT_S3_PATH_STYLE"},
+		},
+		&cli.StringFlag{
+			Name:    "s3-sse",
+			Usage:   "server-side encryption for uploaded transcripts, s3 or kms:<key-id>",
+			EnvVars: []string{"PLUGIN_S3_SSE", "INPUT_S3_SSE"},
+		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -183,8 +502,9 @@ func main() {
 	}
 }
 
-func run(c *cli.Context) error {
-	cfg := config.Setting{
+// newSetting builds the configuration from the command line flags.
+func newSetting(c *cli.Context) config.Setting {
+	return config.Setting{
 		Whisper: config.Whisper{
 			Model:        c.String("model"),
 			AudioPath:    c.String("audio-path"),
@@ -198,9 +518,58 @@ func run(c *cli.Context) error {
 			BeamSize:     c.Uint("beam-size"),
 			EntropyThold: c.Float64("entropy-thold"),
 
+			Temperature:    c.Float64("temperature"),
+			TemperatureInc: c.Float64("temperature-inc"),
+			BestOf:         c.Uint("best-of"),
+			LogprobThold:   c.Float64("logprob-thold"),
+			NoSpeechThold:  c.Float64("no-speech-thold"),
+
+			Offset:           c.Duration("offset"),
+			Duration:         c.Duration("duration"),
+			SplitOnWord:      c.Bool("split-on-word"),
+			MaxSegmentLength: c.Uint("max-len"),
+			MaxTokens:        c.Uint("max-tokens"),
+			TokenTimestamps:  c.Bool("token-timestamps"),
+			TokenThold:       c.Float64("token-thold"),
+			TokenSumThold:    c.Float64("token-sum-thold"),
+			AudioCtx:         c.Uint("audio-ctx"),
+
 			PrintProgress: c.Bool("print-progress"),
 			PrintSegment:  c.Bool("print-segment"),
 
+			VAD: config.VAD{
+				Enabled:    c.Bool("vad"),
+				Threshold:  c.Float64("vad-threshold"),
+				MinSpeech:  c.Duration("vad-min-speech"),
+				MinSilence: c.Duration("vad-min-silence"),
+				Padding:    c.Duration("vad-padding"),
+			},
+
+			Clean: config.Clean{
+				Enabled:     c.Bool("clean"),
+				MaxRepeat:   c.Int("clean-max-repeat"),
+				MinProb:     c.Float64("clean-min-prob"),
+				MinEnergy:   c.Float64("clean-min-energy"),
+				PhrasesFile: c.String("clean-phrases"),
+				KeepRaw:     c.Bool("keep-raw"),
+			},
+
+			Checkpoint: config.Checkpoint{
+				Interval: c.Duration("checkpoint-interval"),
+				Resume:   c.Bool("resume"),
+			},
+
+			Glossary: config.Glossary{
+				File:      c.String("glossary"),
+				Chunk:     c.Duration("glossary-chunk"),
+				Threshold: c.Float64("glossary-threshold"),
+			},
+
+			Rules: config.Rules{
+				File:   c.String("rules"),
+				DryRun: c.Bool("rules-dry-run"),
+			},
+
 			OutputFolder:   c.String("output-folder"),
 			OutputFilename: c.String("output-filename"),
 			OutputFormat:   c.StringSlice("output-format"),
@@ -218,25 +587,199 @@ func run(c *cli.Context) error {
 			Debug:    c.Bool("debug"),
 			Retry:    c.Int("youtube-retry-count"),
 		},
+
+		Source: config.Source{
+			Insecure: c.Bool("youtube-insecure"),
+			Retry:    c.Int("youtube-retry-count"),
+			Checksum: c.String("download-checksum"),
+		},
+
+		S3: config.S3{
+			Endpoint:  c.String("s3-endpoint"),
+			Region:    c.String("s3-region"),
+			AccessKey: c.String("s3-access-key"),
+			SecretKey: c.String("s3-secret-key"),
+			PathStyle: c.Bool("s3-path-style"),
+			SSE:       c.String("s3-sse"),
+		},
+
+		Models: config.Models{
+			Dir:      c.String("model-dir"),
+			BaseURL:  c.String("model-base-url"),
+			Insecure: c.Bool("youtube-insecure"),
+			Retry:    c.Int("youtube-retry-count"),
+		},
+
+		Tracing: config.Tracing{
+			Endpoint: c.String("otlp-endpoint"),
+		},
+
+		Cache: config.Cache{
+			Dir:     c.String("cache-dir"),
+			MaxSize: c.String("cache-max-size"),
+		},
 	}
+}
 
-	if cfg.Whisper.Debug {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-		log.Logger = log.With().Caller().Logger()
+// setupLog applies the log format and level. The loggers of the jobs are
+// attached to their context, the global logger is the fallback.
+func setupLog(c *cli.Context) error {
+	level, err := zerolog.ParseLevel(c.String("log-level"))
+	if err != nil || level == zerolog.NoLevel || level > zerolog.ErrorLevel {
+		return fmt.Errorf("unsupported log level %q, use trace, debug, info, warn or error", c.String("log-level"))
 	}
+	zerolog.SetGlobalLevel(level)
 
-	if cfg.Whisper.Debug {
-		spew.Dump(cfg)
+	switch c.String("log-format") {
+	case "console":
+	case "json":
+		log.Logger = zerolog.New(os.Stderr).With().Timestamp().Logger()
+		whisper.RedirectLogs(true)
+	default:
+		return fmt.Errorf("unsupported log format %q, use console or json", c.String("log-format"))
 	}
+	zerolog.DefaultContextLogger = &log.Logger
 
-	yt, err := youtube.New(&cfg.Youtube)
+	return nil
+}
+
+// setupDebug enables the debug log level and dumps the configuration.
+func setupDebug(cfg *config.Setting) {
+	if !cfg.Whisper.Debug {
+		return
+	}
+
+	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	log.Logger = log.With().Caller().Logger()
+	log.Debug().Interface("config", config.Redact(cfg)).Msg("configuration")
+}
+
+// shutdownTracing flushes the pending spans on exit.
+var shutdownTracing = func(context.Context) error { return nil }
+
+// setupTracing exports the spans if an otlp endpoint is set.
+func setupTracing(c *cli.Context) error {
+	shutdown, err := tracing.Setup(c.Context, &config.Tracing{Endpoint: c.String("otlp-endpoint")}, Version)
+	if err != nil {
+		return err
+	}
+	shutdownTracing = shutdown
+	return nil
+}
+
+// serveMetrics exposes /metrics in the background if addr is set.
+func serveMetrics(addr string) error {
+	if addr == "" {
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", addr)
 	if err != nil {
+		return fmt.Errorf("listen metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil {
+			log.Error().Err(err).Msg("metrics server error")
+		}
+	}()
+	log.Info().Str("addr", ln.Addr().String()).Msg("serve metrics")
+
+	return nil
+}
+
+func run(c *cli.Context) error {
+	cfg := newSetting(c)
+	setupDebug(&cfg)
+
+	if err := resolveModel(&cfg); err != nil {
 		return err
 	}
+
+	out, err := sink.New(cfg.Whisper.OutputFolder, &cfg.S3, cfg.Source.Insecure)
+	if err != nil {
+		return err
+	}
+
+	opts := []whisper.Option{whisper.WithSink(out)}
+	if bar := newProgressBar(c, &cfg); bar != nil {
+		defer bar.Close()
+		if cfg.Youtube.URL != "" || source.Remote(cfg.Whisper.AudioPath) {
+			bar.Phase(progress.PhaseDownload)
+		}
+		opts = append(opts, whisper.WithListener(bar))
+	}
+
+	_, err = processInput(c.Context, &cfg, opts...)
+	return err
+}
+
+// progressBar restores the logger once the bar is closed.
+type progressBar struct {
+	*progress.Bar
+	logger zerolog.Logger
+}
+
+func (b *progressBar) Close() {
+	log.Logger = b.logger
+	b.Bar.Close()
+}
+
+// newProgressBar renders a progress bar instead of the progress logs if the
+// output is a terminal, the logs are printed above the bar meanwhile.
+// It returns nil in non-interactive environments.
+func newProgressBar(c *cli.Context, cfg *config.Setting) *progressBar {
+	if !cfg.Whisper.PrintProgress ||
+		c.String("log-format") != "console" ||
+		cfg.Whisper.OutputFolder == "-" ||
+		!isatty.IsTerminal(os.Stdout.Fd()) ||
+		!isatty.IsTerminal(os.Stderr.Fd()) {
+		return nil
+	}
+
+	bar := &progressBar{Bar: progress.New(os.Stderr), logger: log.Logger}
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: bar.Bar})
+	cfg.Whisper.PrintProgress = false
+
+	return bar
+}
+
+// processInput downloads the input, transcribes it and removes the download.
+func processInput(ctx context.Context, cfg *config.Setting, opts ...whisper.Option) (e *whisper.Engine, err error) {
+	input := cfg.Whisper.AudioPath
+	if cfg.Youtube.URL != "" {
+		input = cfg.Youtube.URL
+	}
+	ctx = log.Ctx(ctx).With().Str("input", config.RedactURL(input)).Logger().WithContext(ctx)
+	ctx, span := tracing.Start(ctx, "job", trace.WithAttributes(attribute.String("input", config.RedactURL(input))))
+	defer func() { tracing.End(span, err) }()
+
+	cleanup, err := prepareInput(ctx, cfg)
+	if err != nil {
+		metrics.Job(err)
+		return nil, err
+	}
+	defer cleanup()
+
+	return transcribe(ctx, cfg, opts...)
+}
+
+// prepareInput downloads the YouTube video or the remote audio, and points
+// the audio path to the local file. The returned function removes the download.
+func prepareInput(ctx context.Context, cfg *config.Setting) (func(), error) {
+	cleanup := func() {}
+
+	yt, err := youtube.New(&cfg.Youtube)
+	if err != nil {
+		return nil, err
+	}
 	if yt != nil && cfg.Youtube.URL != "" {
-		videoPath, err := yt.Download(c.Context)
+		videoPath, err := yt.Download(ctx)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		cfg.Whisper.AudioPath = videoPath
 		if cfg.Whisper.OutputFilename == "" {
@@ -244,28 +787,74 @@ func run(c *cli.Context) error {
 		}
 	}
 
-	e, err := whisper.New(
+	src, err := source.New(cfg.Whisper.AudioPath, &cfg.Source, &cfg.S3)
+	if err != nil {
+		return nil, err
+	}
+	if src != nil {
+		fetchCtx, span := tracing.Start(ctx, "input.download")
+		audioPath, err := src.Fetch(fetchCtx)
+		tracing.End(span, err)
+		if err != nil {
+			src.Close()
+			return nil, err
+		}
+		cleanup = func() { src.Close() }
+		cfg.Whisper.AudioPath = audioPath
+		// the download has a name of its own, the outputs keep the original one
+		if name := src.Filename(); cfg.Whisper.OutputFilename == "" {
+			cfg.Whisper.OutputFilename = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+		// the downloaded file is removed on exit, so keep the outputs in the working directory.
+		if cfg.Whisper.OutputFolder == "" {
+			cfg.Whisper.OutputFolder = "."
+		}
+	}
+
+	return cleanup, nil
+}
+
+// transcribe runs the whisper engine on the configured audio and saves every output format.
+// The returned engine is closed, it only reports the detected language and the outputs.
+func transcribe(ctx context.Context, cfg *config.Setting, opts ...whisper.Option) (e *whisper.Engine, err error) {
+	defer func() { metrics.Job(err) }()
+
+	results, err := cache.New(&cfg.Cache)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err = whisper.New(
 		&cfg.Whisper,
 		webhook.NewClient(
 			cfg.Webhook.URL,
 			cfg.Webhook.Insecure,
 			webhook.ToHeaders(cfg.Webhook.Headers),
 		),
+		append([]whisper.Option{
+			whisper.WithVersion(Version),
+			whisper.WithContext(ctx),
+			whisper.WithCache(results),
+		}, opts...)...,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer e.Close()
 
 	if err := e.Transcript(); err != nil {
-		return err
+		return nil, err
 	}
-	defer e.Close()
 
 	for _, ext := range cfg.Whisper.OutputFormat {
 		if err := e.Save(ext); err != nil {
-			return err
+			return nil, err
 		}
 	}
+	if err := e.SaveReport(); err != nil {
+		return nil, err
+	}
+	e.Complete()
 
-	return nil
+	return e, nil
 }
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/manifest"
+	"github.com/appleboy/go-whisper/sink"
+	"github.com/appleboy/go-whisper/whisper"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+)
+
+func runCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "run",
+		Usage:     "transcribe every input of a manifest with a shared model",
+		ArgsUsage: "<manifest.yaml>",
+		Action:    runManifest,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "results",
+				Usage:   "results file, <manifest>.results.json by default",
+				EnvVars: []string{"PLUGIN_RESULTS", "INPUT_RESULTS"},
+			},
+			&cli.IntFlag{
+				Name:    "concurrency",
+				Usage:   "number of inputs processed at once, overrides the manifest",
+				EnvVars: []string{"PLUGIN_CONCURRENCY", "INPUT_CONCURRENCY"},
+			},
+			&cli.BoolFlag{
+				Name:    "resume",
+				Usage:   "skip the inputs completed in a previous run",
+				EnvVars: []string{"PLUGIN_RESUME", "INPUT_RESUME"},
+				Value:   true,
+			},
+		},
+	}
+}
+
+// job is a manifest item with its configuration.
+type job struct {
+	item manifest.Item
+	cfg  config.Setting
+}
+
+func runManifest(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return errors.New("manifest is required, e.g. go-whisper run manifest.yaml")
+	}
+	name := c.Args().First()
+
+	cfg := newSetting(c)
+	setupDebug(&cfg)
+
+	if err := resolveModel(&cfg); err != nil {
+		return err
+	}
+
+	m, err := manifest.Read(name)
+	if err != nil {
+		return err
+	}
+
+	resultsPath := c.String("results")
+	if resultsPath == "" {
+		resultsPath = manifest.DefaultResultsPath(name)
+	}
+	results, err := manifest.LoadResults(resultsPath)
+	if err != nil {
+		return err
+	}
+
+	// check every item before loading the model
+	var jobs []job
+	for _, item := range m.Items {
+		if c.Bool("resume") && results.Completed(item.ID) {
+			log.Info().Str("job", item.ID).Msg("skip completed input")
+			continue
+		}
+
+		j := job{item: item, cfg: item.Apply(cfg)}
+		check := j.cfg.Whisper
+		if check.AudioPath == "" {
+			check.AudioPath = j.cfg.Youtube.URL
+		}
+		if err := check.Validate(); err != nil {
+			return fmt.Errorf("input %s: %w", item.ID, err)
+		}
+		if err := whisper.CheckFormats(check.OutputFormat); err != nil {
+			return fmt.Errorf("input %s: %w", item.ID, err)
+		}
+		jobs = append(jobs, j)
+	}
+	if len(jobs) == 0 {
+		log.Info().Msg("all inputs are completed")
+		return nil
+	}
+
+	model, err := whisper.LoadModel(cfg.Whisper.Model)
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+
+	out, err := sink.New(cfg.Whisper.OutputFolder, &cfg.S3, cfg.Source.Insecure)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(c.Context, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	concurrency := m.Concurrency
+	if c.Int("concurrency") > 0 {
+		concurrency = c.Int("concurrency")
+	}
+
+	items := make([]manifest.Item, len(jobs))
+	for i := range jobs {
+		items[i] = jobs[i].item
+	}
+	var decode sync.Mutex
+	failed := manifest.Run(ctx, items, concurrency, results, func(ctx context.Context, i int) manifest.Result {
+		return runJob(ctx, &jobs[i],
+			whisper.WithSink(out),
+			whisper.WithModel(model),
+			whisper.WithLock(&decode),
+		)
+	})
+
+	log.Info().
+		Int("total", len(m.Items)).
+		Int("processed", len(jobs)).
+		Int("failed", failed).
+		Str("results", resultsPath).
+		Msg("manifest completed")
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d inputs failed, see %s", failed, len(jobs), resultsPath)
+	}
+
+	return nil
+}
+
+// runJob downloads and transcribes a single item and returns its result.
+func runJob(ctx context.Context, j *job, opts ...whisper.Option) manifest.Result {
+	result := manifest.Result{
+		ID:        j.item.ID,
+		Input:     config.RedactURL(j.item.Input),
+		StartedAt: time.Now(),
+	}
+	logger := log.With().Str("job", j.item.ID).Logger()
+	logger.Info().Msg("start input")
+
+	e, err := processInput(logger.WithContext(ctx), &j.cfg, opts...)
+
+	result.FinishedAt = time.Now()
+	result.Duration = result.FinishedAt.Sub(result.StartedAt).Seconds()
+	if err != nil {
+		logger.Error().Err(err).Msg("input failed")
+		result.Status = manifest.StatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = manifest.StatusCompleted
+	result.Language = e.Language()
+	result.Outputs = e.Outputs()
+	logger.Info().Float64("duration", result.Duration).Msg("input completed")
+
+	return result
+}
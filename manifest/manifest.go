@@ -0,0 +1,204 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/whisper"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Job statuses recorded in the results file.
+const (
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Item is an input of the manifest with its per-item overrides.
+type Item struct {
+	ID             string   `yaml:"id" json:"id,omitempty"`                           // ID identifies the item in the results, the input by default.
+	Input          string   `yaml:"input" json:"input"`                               // Input is a local path, an http(s) or s3 URL, or a YouTube URL.
+	Language       string   `yaml:"language" json:"language,omitempty"`               // Language overrides --language.
+	Prompt         string   `yaml:"prompt" json:"prompt,omitempty"`                   // Prompt overrides --prompt.
+	OutputFormat   []string `yaml:"output-format" json:"output-format,omitempty"`     // OutputFormat overrides --output-format.
+	OutputFilename string   `yaml:"output-filename" json:"output-filename,omitempty"` // OutputFilename overrides --output-filename.
+	WebhookHeaders []string `yaml:"webhook-headers" json:"webhook-headers,omitempty"` // WebhookHeaders are added to --webhook-headers.
+}
+
+// Apply returns a copy of the shared configuration with the overrides of the item.
+func (i *Item) Apply(cfg config.Setting) config.Setting {
+	job := cfg
+	job.Whisper.AudioPath = i.Input
+	job.Youtube.URL = ""
+	if IsYoutube(i.Input) {
+		job.Whisper.AudioPath = ""
+		job.Youtube.URL = i.Input
+	}
+
+	if i.Language != "" {
+		job.Whisper.Language = i.Language
+	}
+	if i.Prompt != "" {
+		job.Whisper.Prompt = i.Prompt
+	}
+	if len(i.OutputFormat) > 0 {
+		job.Whisper.OutputFormat = i.OutputFormat
+	}
+	if i.OutputFilename != "" {
+		job.Whisper.OutputFilename = i.OutputFilename
+	}
+	if len(i.WebhookHeaders) > 0 {
+		job.Webhook.Headers = append(append([]string{}, cfg.Webhook.Headers...), i.WebhookHeaders...)
+	}
+
+	return job
+}
+
+// Manifest lists the inputs of a run.
+type Manifest struct {
+	Concurrency int    `yaml:"concurrency"` // Concurrency is the number of items processed at once.
+	Items       []Item `yaml:"inputs"`
+}
+
+// Read parses a YAML or JSON manifest and checks every item has a unique ID.
+func Read(name string) (*Manifest, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parse manifest %s: %w", name, err)
+	}
+	if len(m.Items) == 0 {
+		return nil, fmt.Errorf("manifest %s has no inputs", name)
+	}
+
+	ids := map[string]bool{}
+	for i := range m.Items {
+		item := &m.Items[i]
+		if item.Input == "" {
+			return nil, fmt.Errorf("manifest %s: input %d is empty", name, i+1)
+		}
+		if item.ID == "" {
+			item.ID = item.Input
+		}
+		if ids[item.ID] {
+			return nil, fmt.Errorf("manifest %s: duplicate id %q", name, item.ID)
+		}
+		ids[item.ID] = true
+	}
+
+	return m, nil
+}
+
+// IsYoutube reports whether the input is a YouTube video URL.
+func IsYoutube(input string) bool {
+	u, err := url.Parse(input)
+	if err != nil {
+		return false
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	return host == "youtube.com" || host == "youtu.be" || host == "music.youtube.com"
+}
+
+// Result is the outcome of an item.
+type Result struct {
+	ID         string           `json:"id"`
+	Input      string           `json:"input"`
+	Status     string           `json:"status"`
+	Error      string           `json:"error,omitempty"`
+	Duration   float64          `json:"duration"` // Duration is the processing time in seconds.
+	Language   string           `json:"language,omitempty"`
+	Outputs    []whisper.Output `json:"outputs,omitempty"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+}
+
+// Results is the results file of a run. It is rewritten after every item,
+// so an interrupted run can be resumed.
+type Results struct {
+	path  string
+	mu    sync.Mutex
+	Items []Result `json:"items"`
+}
+
+// LoadResults reads the results file, or returns empty results if it doesn't exist.
+func LoadResults(path string) (*Results, error) {
+	r := &Results{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("parse results %s: %w", path, err)
+	}
+
+	return r, nil
+}
+
+// Completed reports whether the item already completed in a previous run.
+func (r *Results) Completed(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, item := range r.Items {
+		if item.ID == id {
+			return item.Status == StatusCompleted
+		}
+	}
+	return false
+}
+
+// Set records the result of an item and writes the results file.
+func (r *Results) Set(result Result) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found := false
+	for i := range r.Items {
+		if r.Items[i].ID == result.ID {
+			r.Items[i] = result
+			found = true
+			break
+		}
+	}
+	if !found {
+		r.Items = append(r.Items, result)
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so a crash never leaves a truncated file
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, r.path)
+}
+
+// DefaultResultsPath returns the results file next to the manifest,
+// e.g. weekly.results.json for weekly.yaml.
+func DefaultResultsPath(manifest string) string {
+	return strings.TrimSuffix(manifest, filepath.Ext(manifest)) + ".results.json"
+}
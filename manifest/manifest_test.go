@@ -0,0 +1,135 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *Manifest
+		wantErr bool
+	}{
+		{
+			name: "inputs with overrides",
+			content: `
+concurrency: 2
+inputs:
+  - input: episodes/001.mp3
+  - id: interview
+    input: https://www.youtube.com/watch?v=abc
+    language: de
+    output-format: [srt]
+    webhook-headers: ["X-Item=interview"]
+`,
+			want: &Manifest{
+				Concurrency: 2,
+				Items: []Item{
+					{ID: "episodes/001.mp3", Input: "episodes/001.mp3"},
+					{
+						ID:             "interview",
+						Input:          "https://www.youtube.com/watch?v=abc",
+						Language:       "de",
+						OutputFormat:   []string{"srt"},
+						WebhookHeaders: []string{"X-Item=interview"},
+					},
+				},
+			},
+		},
+		{
+			name:    "no inputs",
+			content: "concurrency: 2\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty input",
+			content: "inputs:\n  - id: a\n",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate id",
+			content: "inputs:\n  - input: a.wav\n  - input: a.wav\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "manifest.yaml")
+			if err := os.WriteFile(name, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Read(name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Read() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsYoutube(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "https://www.youtube.com/watch?v=abc", want: true},
+		{input: "https://youtu.be/abc", want: true},
+		{input: "https://example.com/youtube.com.wav", want: false},
+		{input: "episodes/001.mp3", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := IsYoutube(tt.input); got != tt.want {
+				t.Errorf("IsYoutube() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.results.json")
+
+	r, err := LoadResults(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Set(Result{ID: "a", Status: StatusCompleted}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Set(Result{ID: "b", Status: StatusFailed}); err != nil {
+		t.Fatal(err)
+	}
+	// a retried item replaces its previous result
+	if err := r.Set(Result{ID: "b", Status: StatusCompleted}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = LoadResults(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Items) != 2 {
+		t.Fatalf("LoadResults() items = %d, want 2", len(r.Items))
+	}
+	for _, id := range []string{"a", "b"} {
+		if !r.Completed(id) {
+			t.Errorf("Results.Completed(%s) = false, want true", id)
+		}
+	}
+	if r.Completed("c") {
+		t.Errorf("Results.Completed(c) = true, want false")
+	}
+}
+
+func TestDefaultResultsPath(t *testing.T) {
+	if got := DefaultResultsPath("jobs/weekly.yaml"); got != "jobs/weekly.results.json" {
+		t.Errorf("DefaultResultsPath() = %v", got)
+	}
+}
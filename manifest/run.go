@@ -0,0 +1,92 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Run processes the items with the given number of workers and records
+// every result as soon as it is known, so a failed item doesn't stop the
+// others. A panic of an item is recorded as its failure. Run stops queuing
+// the items once the context is canceled and returns the number of failed
+// items.
+func Run(ctx context.Context, items []Item, concurrency int, results *Results, run func(ctx context.Context, i int) Result) int {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed int
+		queue  = make(chan int)
+	)
+	metrics.Queued(len(items))
+	for w := 0; w < max(concurrency, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				metrics.Queued(-1)
+				result := safeRun(ctx, &items[i], func(ctx context.Context) Result {
+					return run(ctx, i)
+				})
+				if result.Status != StatusCompleted {
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+				if err := results.Set(result); err != nil {
+					log.Error().Err(err).Str("results", results.path).Msg("write results error")
+				}
+			}
+		}()
+	}
+
+	for i := range items {
+		sent := false
+		select {
+		case queue <- i:
+			sent = true
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			// the remaining items won't be processed, a sent item is
+			// dequeued by its worker
+			remaining := len(items) - i
+			if sent {
+				remaining--
+			}
+			metrics.Queued(-remaining)
+			break
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	return failed
+}
+
+// safeRun runs an item and turns a panic into a failed result.
+func safeRun(ctx context.Context, item *Item, run func(ctx context.Context) Result) (result Result) {
+	started := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Str("job", item.ID).Interface("panic", r).Msg("input failed")
+			result = Result{
+				ID:         item.ID,
+				Input:      config.RedactURL(item.Input),
+				Status:     StatusFailed,
+				Error:      fmt.Sprintf("panic: %v", r),
+				StartedAt:  started,
+				FinishedAt: time.Now(),
+			}
+			result.Duration = result.FinishedAt.Sub(started).Seconds()
+		}
+	}()
+
+	return run(ctx)
+}
@@ -0,0 +1,116 @@
+package manifest
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/appleboy/go-whisper/metrics"
+)
+
+func TestRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.results.json")
+	r, err := LoadResults(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := []Item{
+		{ID: "a", Input: "a.wav"},
+		{ID: "bad", Input: "https://www.youtube.com/watch?v=bad"},
+		{ID: "panic", Input: "panic.wav"},
+		{ID: "c", Input: "c.wav"},
+	}
+	failed := Run(context.Background(), items, 2, r, func(_ context.Context, i int) Result {
+		result := Result{ID: items[i].ID, Input: items[i].Input, Status: StatusCompleted}
+		switch items[i].ID {
+		case "bad":
+			result.Status = StatusFailed
+			result.Error = "get youtube video: not found"
+		case "panic":
+			panic("boom")
+		}
+		return result
+	})
+	if failed != 2 {
+		t.Errorf("Run() failed = %d, want 2", failed)
+	}
+
+	r, err = LoadResults(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Items) != len(items) {
+		t.Fatalf("LoadResults() items = %d, want %d", len(r.Items), len(items))
+	}
+	want := map[string]bool{"a": true, "bad": false, "panic": false, "c": true}
+	for id, completed := range want {
+		if got := r.Completed(id); got != completed {
+			t.Errorf("Results.Completed(%s) = %v, want %v", id, got, completed)
+		}
+	}
+}
+
+func TestRun_Canceled(t *testing.T) {
+	r, err := LoadResults(filepath.Join(t.TempDir(), "manifest.results.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	items := []Item{{ID: "a"}, {ID: "b"}}
+	calls := 0
+	Run(ctx, items, 1, r, func(_ context.Context, i int) Result {
+		calls++
+		return Result{ID: items[i].ID, Status: StatusCompleted}
+	})
+	if calls > 1 {
+		t.Errorf("Run() processed %d items after cancel, want at most 1", calls)
+	}
+}
+
+func TestRun_CanceledQueueDepth(t *testing.T) {
+	r, err := LoadResults(filepath.Join(t.TempDir(), "manifest.results.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := queueDepth(t)
+	// an idle worker may still receive an item after the cancel, run it often
+	for range 50 {
+		ctx, cancel := context.WithCancel(context.Background())
+		items := []Item{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+		Run(ctx, items, 2, r, func(_ context.Context, i int) Result {
+			cancel()
+			return Result{ID: items[i].ID, Status: StatusCompleted}
+		})
+		cancel()
+	}
+	if got := queueDepth(t); got != before {
+		t.Errorf("queue depth = %v, want %v", got, before)
+	}
+}
+
+// queueDepth returns the queue depth gauge.
+func queueDepth(t *testing.T) float64 {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	for _, line := range strings.Split(string(body), "\n") {
+		if v, ok := strings.CutPrefix(line, "whisper_queue_depth "); ok {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return n
+		}
+	}
+	return 0
+}
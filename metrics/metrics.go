@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "whisper"
+
+// Job statuses.
+const (
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+var (
+	registry = prometheus.NewRegistry()
+
+	jobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_total",
+		Help:      "Transcription jobs by status.",
+	}, []string{"status"})
+
+	audioSeconds = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audio_seconds_total",
+		Help:      "Seconds of audio transcribed.",
+	})
+
+	realtimeFactor = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "realtime_factor",
+		Help:      "Decoding time divided by the audio duration, below 1 is faster than real time.",
+		Buckets:   []float64{0.05, 0.1, 0.2, 0.3, 0.5, 0.75, 1, 1.5, 2, 3, 5},
+	})
+
+	modelLoad = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "model_load_seconds",
+		Help:      "Time to load the model.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 10),
+	})
+
+	queueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "queue_depth",
+		Help:      "Jobs waiting for a worker.",
+	})
+
+	webhookAttempts = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_attempts_total",
+		Help:      "Webhook deliveries attempted.",
+	})
+
+	webhookFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_failures_total",
+		Help:      "Webhook deliveries failed by status code, error if no response was received.",
+	}, []string{"code"})
+
+	youtubeDownload = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "youtube_download_seconds",
+		Help:      "Time to download a YouTube video.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 10),
+	})
+
+	youtubeRetries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "youtube_download_retries_total",
+		Help:      "YouTube downloads retried.",
+	})
+
+	conversion = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ffmpeg_conversion_seconds",
+		Help:      "Time to convert the audio to 16 kHz mono wav with ffmpeg.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 10),
+	})
+
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Result cache lookups by result, hit or miss.",
+	}, []string{"result"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		jobs,
+		audioSeconds,
+		realtimeFactor,
+		modelLoad,
+		queueDepth,
+		webhookAttempts,
+		webhookFailures,
+		youtubeDownload,
+		youtubeRetries,
+		conversion,
+		cacheLookups,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// WriteFile writes the metrics in the Prometheus text format to path, e.g. for
+// the textfile collector of the node exporter. The file is replaced atomically.
+func WriteFile(path string) error {
+	return prometheus.WriteToTextfile(path, registry)
+}
+
+// Job counts a finished job, failed if err is not nil.
+func Job(err error) {
+	if err != nil {
+		jobs.WithLabelValues(StatusFailed).Inc()
+		return
+	}
+	jobs.WithLabelValues(StatusCompleted).Inc()
+}
+
+// Decoded records the audio transcribed in elapsed time.
+func Decoded(audio, elapsed time.Duration) {
+	if audio <= 0 {
+		return
+	}
+	audioSeconds.Add(audio.Seconds())
+	realtimeFactor.Observe(elapsed.Seconds() / audio.Seconds())
+}
+
+// ModelLoaded records the time to load the model.
+func ModelLoaded(elapsed time.Duration) {
+	modelLoad.Observe(elapsed.Seconds())
+}
+
+// Queued adds delta jobs to the queue depth, negative once picked by a worker.
+func Queued(delta int) {
+	queueDepth.Add(float64(delta))
+}
+
+// Webhook counts a webhook delivery. code is the response status, 0 if no
+// response was received.
+func Webhook(code int, failed bool) {
+	webhookAttempts.Inc()
+	if !failed {
+		return
+	}
+
+	label := "error"
+	if code > 0 {
+		label = strconv.Itoa(code)
+	}
+	webhookFailures.WithLabelValues(label).Inc()
+}
+
+// YoutubeDownloaded records the time to download a video.
+func YoutubeDownloaded(elapsed time.Duration) {
+	youtubeDownload.Observe(elapsed.Seconds())
+}
+
+// YoutubeRetried counts a retried download.
+func YoutubeRetried() {
+	youtubeRetries.Inc()
+}
+
+// Converted records the time of an ffmpeg conversion.
+func Converted(elapsed time.Duration) {
+	conversion.Observe(elapsed.Seconds())
+}
+
+// Cached counts a result cache lookup.
+func Cached(hit bool) {
+	if hit {
+		cacheLookups.WithLabelValues("hit").Inc()
+		return
+	}
+	cacheLookups.WithLabelValues("miss").Inc()
+}
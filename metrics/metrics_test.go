@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestWebhook(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   int
+		failed bool
+		label  string
+	}{
+		{name: "delivered", code: 200},
+		{name: "server error", code: 502, failed: true, label: "502"},
+		{name: "no response", failed: true, label: "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := testutil.ToFloat64(webhookAttempts)
+			var failures float64
+			if tt.label != "" {
+				failures = testutil.ToFloat64(webhookFailures.WithLabelValues(tt.label))
+			}
+
+			Webhook(tt.code, tt.failed)
+
+			if got := testutil.ToFloat64(webhookAttempts) - attempts; got != 1 {
+				t.Errorf("attempts = %v, want 1", got)
+			}
+			if tt.label == "" {
+				return
+			}
+			if got := testutil.ToFloat64(webhookFailures.WithLabelValues(tt.label)) - failures; got != 1 {
+				t.Errorf("failures{code=%s} = %v, want 1", tt.label, got)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	Job(nil)
+	Job(errors.New("boom"))
+	Decoded(10*time.Second, 2*time.Second)
+	Queued(2)
+	Queued(-1)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	for _, want := range []string{
+		`whisper_jobs_total{status="completed"} 1`,
+		`whisper_jobs_total{status="failed"} 1`,
+		`whisper_audio_seconds_total 10`,
+		`whisper_realtime_factor_bucket{le="0.2"} 1`,
+		`whisper_queue_depth 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	YoutubeRetried()
+
+	path := filepath.Join(t.TempDir(), "whisper.prom")
+	if err := WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "whisper_youtube_download_retries_total") {
+		t.Errorf("metrics file missing the retries: %s", body)
+	}
+}
@@ -0,0 +1,287 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/source"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultBaseURL is the location of the ggml models converted by whisper.cpp.
+const DefaultBaseURL = "https://huggingface.co/ggerganov/whisper.cpp/resolve/main"
+
+// verifiedExt is the suffix of the stamp written next to a verified model.
+const verifiedExt = ".verified"
+
+// Info describes a known whisper model.
+type Info struct {
+	Name string // Name is the alias used with --model, e.g. small.
+	File string // File is the file name of the model.
+	Size string // Size is the download size.
+	SHA1 string // SHA1 is the expected digest of the file.
+}
+
+// Models are the official ggml models with their SHA-1 digests.
+var Models = []Info{
+	{Name: "tiny", File: "ggml-tiny.bin", Size: "75 MB", SHA1: "bd577a113a864445d4c299885e0cb97d4ba92b5f"},
+	{Name: "tiny.en", File: "ggml-tiny.en.bin", Size: "75 MB", SHA1: "c78c86eb1a8faa21b369bcd33207cc90d64ae9df"},
+	{Name: "base", File: "ggml-base.bin", Size: "142 MB", SHA1: "465707469ff3a37a2b9b8d8f89f2f99de7299dac"},
+	{Name: "base.en", File: "ggml-base.en.bin", Size: "142 MB", SHA1: "137c40403d78fd54d454da0f9bd998f78703390c"},
+	{Name: "small", File: "ggml-small.bin", Size: "466 MB", SHA1: "55356645c2b361a969dfd0ef2c5a50d530afd8d5"},
+	{Name: "small.en", File: "ggml-small.en.bin", Size: "466 MB", SHA1: "db8a495a91d927739e50b3fc1cc4c6b8f6c2d022"},
+	{Name: "medium", File: "ggml-medium.bin", Size: "1.5 GB", SHA1: "fd9727b6e1217c2f614f9b698455c4ffd82463b4"},
+	{Name: "medium.en", File: "ggml-medium.en.bin", Size: "1.5 GB", SHA1: "8c30f0e44ce9560643ebd10bbe50cd20eafd3723"},
+	{Name: "large-v1", File: "ggml-large-v1.bin", Size: "2.9 GB", SHA1: "b1caaf735c4cc1429223d5a74f0f4d0b9b59a299"},
+	{Name: "large", File: "ggml-large.bin", Size: "2.9 GB", SHA1: "0f4c8e34f21cf1a914c59d8b3ce882345ad349d6"},
+}
+
+// Status is a known model and its state in the cache.
+type Status struct {
+	Info
+	Path       string // Path is the location of the model in the cache.
+	Downloaded bool   // Downloaded reports whether the model file exists.
+}
+
+// Manager downloads, verifies and removes the models in a local cache directory.
+type Manager struct {
+	cfg    *config.Models
+	models []Info
+	client *http.Client
+}
+
+// DefaultDir returns the default cache directory, e.g. ~/.cache/go-whisper/models.
+func DefaultDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "models"
+	}
+
+	return filepath.Join(dir, "go-whisper", "models")
+}
+
+// NewManager creates a new model manager.
+func NewManager(cfg *config.Models) *Manager {
+	if cfg.Dir == "" {
+		cfg.Dir = DefaultDir()
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultBaseURL
+	}
+
+	return &Manager{
+		cfg:    cfg,
+		models: Models,
+		client: &http.Client{
+			Transport: source.NewTransport(cfg.Insecure),
+		},
+	}
+}
+
+// Lookup returns the model with the given alias or file name.
+func (m *Manager) Lookup(name string) (Info, error) {
+	for _, info := range m.models {
+		if info.Name == name || info.File == name {
+			return info, nil
+		}
+	}
+
+	names := make([]string, len(m.models))
+	for i, info := range m.models {
+		names[i] = info.Name
+	}
+	return Info{}, fmt.Errorf("unknown model %q, available models: %s", name, strings.Join(names, ", "))
+}
+
+// Path returns the location of the model in the cache.
+func (m *Manager) Path(info Info) string {
+	return filepath.Join(m.cfg.Dir, info.File)
+}
+
+// List returns every known model and whether it is downloaded.
+func (m *Manager) List() []Status {
+	result := make([]Status, len(m.models))
+	for i, info := range m.models {
+		p := m.Path(info)
+		_, err := os.Stat(p)
+		result[i] = Status{
+			Info:       info,
+			Path:       p,
+			Downloaded: err == nil,
+		}
+	}
+
+	return result
+}
+
+// Pull downloads the model into the cache and verifies the checksum.
+// An interrupted download is resumed from the partial file.
+func (m *Manager) Pull(ctx context.Context, name string) (string, error) {
+	info, err := m.Lookup(name)
+	if err != nil {
+		return "", err
+	}
+
+	output := m.Path(info)
+	if _, err := os.Stat(output); err == nil {
+		if err := m.verify(info, output); err == nil {
+			log.Info().Str("model", info.Name).Str("path", output).Msg("model is up to date")
+			return output, nil
+		}
+		log.Warn().Str("model", info.Name).Msg("cached model is corrupted, download again")
+		if err := m.remove(output); err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.MkdirAll(m.cfg.Dir, 0o755); err != nil {
+		return "", err
+	}
+
+	url := strings.TrimSuffix(m.cfg.BaseURL, "/") + "/" + info.File
+	partial := output + ".part"
+
+	log.Info().Str("model", info.Name).Str("url", config.RedactURL(url)).Msg("download model")
+	err = source.Retry(ctx, m.cfg.Retry, func() error {
+		if err := source.Download(ctx, m.client, url, partial); err != nil {
+			log.Warn().Err(err).Str("url", config.RedactURL(url)).Msg("download failed")
+			return err
+		}
+
+		if err := source.VerifyChecksum(partial, "sha1:"+info.SHA1); err != nil {
+			// start from scratch on the next attempt
+			_ = os.Remove(partial)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(partial, output); err != nil {
+		return "", err
+	}
+
+	return output, m.stamp(output, info.SHA1)
+}
+
+// Verify checks the cached model against its SHA-1 digest.
+func (m *Manager) Verify(name string) error {
+	info, err := m.Lookup(name)
+	if err != nil {
+		return err
+	}
+
+	output := m.Path(info)
+	if _, err := os.Stat(output); err != nil {
+		return fmt.Errorf("model %s is not downloaded, run: go-whisper models pull %s", info.Name, info.Name)
+	}
+
+	// always hash the file, ignoring the stamp
+	_ = os.Remove(output + verifiedExt)
+	return m.verify(info, output)
+}
+
+// Remove deletes the model from the cache.
+func (m *Manager) Remove(name string) error {
+	info, err := m.Lookup(name)
+	if err != nil {
+		return err
+	}
+
+	output := m.Path(info)
+	if _, err := os.Stat(output); err != nil {
+		return fmt.Errorf("model %s is not downloaded", info.Name)
+	}
+
+	return m.remove(output)
+}
+
+func (m *Manager) remove(output string) error {
+	for _, name := range []string{output, output + verifiedExt, output + ".part"} {
+		if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resolve returns the model file for the --model value. A path to an existing
+// file is used as is, otherwise the value is an alias of a downloaded model.
+// Cached models are verified, so a corrupted file fails before decoding.
+func (m *Manager) Resolve(model string) (string, error) {
+	if model == "" {
+		return "", nil
+	}
+
+	if _, err := os.Stat(model); err == nil {
+		return model, nil
+	}
+
+	info, err := m.Lookup(model)
+	if err != nil {
+		return "", fmt.Errorf("model %s not found: %w", model, err)
+	}
+
+	output := m.Path(info)
+	if _, err := os.Stat(output); err != nil {
+		return "", fmt.Errorf("model %s is not downloaded, run: go-whisper models pull %s", info.Name, info.Name)
+	}
+
+	return output, m.verify(info, output)
+}
+
+// verify checks the SHA-1 digest of the file. The result is cached in a stamp
+// next to the file, so the model is only hashed again after it changed.
+func (m *Manager) verify(info Info, output string) error {
+	stat, err := os.Stat(output)
+	if err != nil {
+		return err
+	}
+
+	want := stampContent(stat, info.SHA1)
+	if got, err := os.ReadFile(output + verifiedExt); err == nil && string(got) == want {
+		return nil
+	}
+
+	log.Debug().Str("model", info.Name).Msg("verify model checksum")
+	if err := source.VerifyChecksum(output, "sha1:"+info.SHA1); err != nil {
+		var e *source.ChecksumError
+		if errors.As(err, &e) {
+			return fmt.Errorf("model %s is corrupted, remove it and pull again: %w", output, err)
+		}
+		return err
+	}
+
+	// the stamp is only an optimization, the model is valid anyway
+	if err := m.stamp(output, info.SHA1); err != nil {
+		log.Debug().Err(err).Msg("write model stamp error")
+	}
+
+	return nil
+}
+
+func (m *Manager) stamp(output, sum string) error {
+	stat, err := os.Stat(output)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(output+verifiedExt, []byte(stampContent(stat, sum)), 0o644)
+}
+
+// stampContent identifies a verified file by its size and modification time.
+func stampContent(stat os.FileInfo, sum string) string {
+	return strconv.FormatInt(stat.Size(), 10) + " " +
+		strconv.FormatInt(stat.ModTime().UnixNano(), 10) + " " + sum + "\n"
+}
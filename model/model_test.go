@@ -0,0 +1,167 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+)
+
+// newTestManager returns a manager serving a single fake model from a local stand-in.
+func newTestManager(t *testing.T, content []byte) (*Manager, *[]string) {
+	t.Helper()
+
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ggml-test.bin" {
+			http.NotFound(w, r)
+			return
+		}
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "ggml-test.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+
+	sum := sha1.Sum(content)
+	m := NewManager(&config.Models{
+		Dir:     t.TempDir(),
+		BaseURL: srv.URL,
+	})
+	m.models = []Info{
+		{Name: "test", File: "ggml-test.bin", SHA1: hex.EncodeToString(sum[:])},
+	}
+
+	return m, &ranges
+}
+
+func TestManager_Pull(t *testing.T) {
+	content := []byte(strings.Repeat("ggml", 1000))
+
+	tests := []struct {
+		name       string
+		partial    []byte
+		wantRanges []string
+	}{
+		{
+			name:       "download from scratch",
+			wantRanges: []string{""},
+		},
+		{
+			name:       "resume partial download",
+			partial:    content[:1000],
+			wantRanges: []string{"bytes=1000-"},
+		},
+		{
+			name:       "restart corrupted partial download",
+			partial:    []byte(strings.Repeat("x", 1000)),
+			wantRanges: []string{"bytes=1000-", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ranges := newTestManager(t, content)
+			m.cfg.Retry = 2
+
+			if tt.partial != nil {
+				if err := os.WriteFile(filepath.Join(m.cfg.Dir, "ggml-test.bin.part"), tt.partial, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := m.Pull(context.Background(), "test")
+			if err != nil {
+				t.Fatalf("Manager.Pull() error = %v", err)
+			}
+
+			data, err := os.ReadFile(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, content) {
+				t.Errorf("Manager.Pull() content mismatch")
+			}
+			if strings.Join(*ranges, ",") != strings.Join(tt.wantRanges, ",") {
+				t.Errorf("Manager.Pull() ranges = %q, want %q", *ranges, tt.wantRanges)
+			}
+		})
+	}
+}
+
+func TestManager_Resolve(t *testing.T) {
+	content := []byte(strings.Repeat("ggml", 1000))
+	m, _ := newTestManager(t, content)
+
+	local := filepath.Join(t.TempDir(), "custom.bin")
+	if err := os.WriteFile(local, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Resolve("test"); err == nil {
+		t.Errorf("Manager.Resolve() expected error for a model that isn't downloaded")
+	}
+
+	want, err := m.Pull(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		model   string
+		want    string
+		wantErr bool
+	}{
+		{name: "alias", model: "test", want: want},
+		{name: "file name", model: "ggml-test.bin", want: want},
+		{name: "local file", model: local, want: local},
+		{name: "unknown alias", model: "huge", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Resolve(tt.model)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Manager.Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Manager.Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// a corrupted model fails fast
+	if err := os.WriteFile(want, []byte("corrupted"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Resolve("test"); err == nil {
+		t.Errorf("Manager.Resolve() expected error for a corrupted model")
+	}
+}
+
+func TestManager_Remove(t *testing.T) {
+	m, _ := newTestManager(t, []byte("ggml"))
+
+	p, err := m.Pull(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Remove("test"); err != nil {
+		t.Fatalf("Manager.Remove() error = %v", err)
+	}
+	for _, name := range []string{p, p + verifiedExt} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("Manager.Remove() left %s", name)
+		}
+	}
+	if m.List()[0].Downloaded {
+		t.Errorf("Manager.List() reports a removed model as downloaded")
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/model"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+)
+
+func modelsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "models",
+		Usage: "manage the local model cache",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "list",
+				Usage:  "list the known models and whether they are downloaded",
+				Action: modelsList,
+			},
+			{
+				Name:      "pull",
+				Usage:     "download the models and verify the checksums",
+				ArgsUsage: "<name>...",
+				Action:    modelsPull,
+			},
+			{
+				Name:      "verify",
+				Usage:     "verify the checksums of the downloaded models",
+				ArgsUsage: "[name]...",
+				Action:    modelsVerify,
+			},
+			{
+				Name:      "rm",
+				Usage:     "remove the models from the cache",
+				ArgsUsage: "<name>...",
+				Action:    modelsRemove,
+			},
+		},
+	}
+}
+
+func newManager(c *cli.Context) *model.Manager {
+	cfg := newSetting(c)
+	return model.NewManager(&cfg.Models)
+}
+
+func modelsList(c *cli.Context) error {
+	m := newManager(c)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tSHA1\tPATH")
+	for _, s := range m.List() {
+		p := "-"
+		if s.Downloaded {
+			p = s.Path
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.Size, s.SHA1, p)
+	}
+
+	return w.Flush()
+}
+
+func modelsPull(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return errors.New("model name is required, e.g. go-whisper models pull small")
+	}
+
+	m := newManager(c)
+	for _, name := range c.Args().Slice() {
+		p, err := m.Pull(c.Context, name)
+		if err != nil {
+			return err
+		}
+		log.Info().Str("model", name).Str("path", p).Msg("model is ready")
+	}
+
+	return nil
+}
+
+func modelsVerify(c *cli.Context) error {
+	m := newManager(c)
+
+	names := c.Args().Slice()
+	if len(names) == 0 {
+		for _, s := range m.List() {
+			if s.Downloaded {
+				names = append(names, s.Name)
+			}
+		}
+	}
+
+	var failed int
+	for _, name := range names {
+		if err := m.Verify(name); err != nil {
+			log.Error().Err(err).Str("model", name).Msg("verify model")
+			failed++
+			continue
+		}
+		log.Info().Str("model", name).Msg("checksum ok")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d models failed verification", failed, len(names))
+	}
+
+	return nil
+}
+
+func modelsRemove(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return errors.New("model name is required, e.g. go-whisper models rm small")
+	}
+
+	m := newManager(c)
+	for _, name := range c.Args().Slice() {
+		if err := m.Remove(name); err != nil {
+			return err
+		}
+		log.Info().Str("model", name).Msg("model removed")
+	}
+
+	return nil
+}
+
+// resolveModel turns a model alias such as small into the cached model file.
+func resolveModel(cfg *config.Setting) error {
+	p, err := model.NewManager(&cfg.Models).Resolve(cfg.Whisper.Model)
+	if err != nil {
+		return err
+	}
+	cfg.Whisper.Model = p
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/appleboy/go-whisper/whisper"
+
+	"github.com/vbauerster/mpb/v8"
+	"github.com/vbauerster/mpb/v8/decor"
+)
+
+// Phases shown besides the ones of the engine.
+const (
+	PhaseDownload = "download"
+	PhaseDone     = "done"
+)
+
+// previewWidth is the number of characters of the latest segment shown.
+const previewWidth = 40
+
+// Bar renders a run on a terminal: the phase, the transcript progress, the
+// elapsed time, the estimated time left and the latest segment. It implements
+// whisper.PhaseListener, and io.Writer to print the logs above the bar.
+type Bar struct {
+	p   *mpb.Progress
+	bar *mpb.Bar
+
+	mu      sync.Mutex
+	phase   string
+	percent int
+	started time.Time // started is when the transcription began.
+	segment string
+}
+
+// New renders the bar to w until Close is called. It starts in the convert
+// phase, call Phase with PhaseDownload first for a remote input.
+func New(w io.Writer) *Bar {
+	b := &Bar{phase: whisper.PhaseConvert}
+	b.p = mpb.New(mpb.WithOutput(w), mpb.WithAutoRefresh(), mpb.WithWidth(30), mpb.WithRefreshRate(200*time.Millisecond))
+	b.bar = b.p.AddBar(100,
+		mpb.PrependDecorators(
+			decor.Any(func(decor.Statistics) string { return b.Status() }, decor.WCSyncSpaceR),
+			decor.Percentage(decor.WCSyncSpace),
+		),
+		mpb.AppendDecorators(
+			decor.Elapsed(decor.ET_STYLE_MMSS, decor.WCSyncSpace),
+			decor.Any(func(decor.Statistics) string { return b.Preview() }, decor.WCSyncSpace),
+		),
+	)
+	return b
+}
+
+// Phase implements whisper.PhaseListener.
+func (b *Bar) Phase(phase string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.phase = phase
+	if phase == whisper.PhaseTranscribe && b.started.IsZero() {
+		b.started = time.Now()
+	}
+}
+
+// Progress implements whisper.Listener.
+func (b *Bar) Progress(percent int) {
+	b.mu.Lock()
+	b.percent = percent
+	b.mu.Unlock()
+
+	b.bar.SetCurrent(int64(percent))
+}
+
+// Segment implements whisper.Listener.
+func (b *Bar) Segment(segment whisper.Segment) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.segment = strings.TrimSpace(segment.Text)
+}
+
+// Status returns the phase and, while transcribing, the time left.
+func (b *Bar) Status() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.phase != whisper.PhaseTranscribe {
+		return b.phase
+	}
+	return fmt.Sprintf("%s ETA %s", b.phase, eta(time.Since(b.started), b.percent))
+}
+
+// Preview returns the end of the latest segment.
+func (b *Bar) Preview() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	text := []rune(b.segment)
+	if len(text) > previewWidth {
+		text = append([]rune("…"), text[len(text)-previewWidth+1:]...)
+	}
+	return string(text)
+}
+
+// Write prints p above the bar.
+func (b *Bar) Write(p []byte) (int, error) {
+	return b.p.Write(p)
+}
+
+// Close completes the bar and waits until it is rendered.
+func (b *Bar) Close() {
+	b.Phase(PhaseDone)
+	b.bar.SetTotal(-1, true)
+	b.p.Wait()
+}
+
+// eta estimates the time left from the time spent on the processed part of
+// the audio, i.e. the real-time factor so far applied to the remaining audio.
+func eta(elapsed time.Duration, percent int) string {
+	if percent <= 0 {
+		return "--:--"
+	}
+	if percent >= 100 {
+		return "00:00"
+	}
+
+	left := time.Duration(float64(elapsed) * float64(100-percent) / float64(percent)).Round(time.Second)
+	return fmt.Sprintf("%02d:%02d", int(left.Minutes()), int(left.Seconds())%60)
+}
@@ -0,0 +1,65 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appleboy/go-whisper/whisper"
+)
+
+func TestEta(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		percent int
+		want    string
+	}{
+		{name: "not started", elapsed: time.Minute, percent: 0, want: "--:--"},
+		{name: "quarter", elapsed: time.Minute, percent: 25, want: "03:00"},
+		{name: "half", elapsed: 90 * time.Second, percent: 50, want: "01:30"},
+		{name: "done", elapsed: time.Hour, percent: 100, want: "00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eta(tt.elapsed, tt.percent); got != tt.want {
+				t.Errorf("eta() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBar(t *testing.T) {
+	var buf bytes.Buffer
+	bar := New(&buf)
+
+	if got := bar.Status(); got != whisper.PhaseConvert {
+		t.Errorf("Status() = %q, want %q", got, whisper.PhaseConvert)
+	}
+	bar.Phase(PhaseDownload)
+	if got := bar.Status(); got != PhaseDownload {
+		t.Errorf("Status() = %q, want %q", got, PhaseDownload)
+	}
+
+	bar.Phase(whisper.PhaseTranscribe)
+	bar.Progress(40)
+	if got := bar.Status(); !strings.HasPrefix(got, "transcribe ETA ") {
+		t.Errorf("Status() = %q, want the ETA while transcribing", got)
+	}
+
+	bar.Segment(whisper.Segment{Text: " And so my fellow Americans, ask not what your country can do for you"})
+	if got := bar.Preview(); got != "…sk not what your country can do for you" {
+		t.Errorf("Preview() = %q, want the end of the segment", got)
+	}
+
+	if _, err := bar.Write([]byte("log line\n")); err != nil {
+		t.Fatal(err)
+	}
+	bar.Progress(100)
+	bar.Close()
+
+	if !strings.Contains(buf.String(), "log line") || !strings.Contains(buf.String(), "100 %") {
+		t.Errorf("output = %q, want the log line and the completed bar", buf.String())
+	}
+}
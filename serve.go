@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/server"
+	"github.com/appleboy/go-whisper/sink"
+	"github.com/appleboy/go-whisper/whisper"
+
+	"github.com/urfave/cli/v2"
+)
+
+func serverCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "server",
+		Usage:  "serve an http api to queue jobs and follow their progress and segments as server-sent events",
+		Action: serve,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "addr",
+				Usage:   "listen address of the api",
+				EnvVars: []string{"PLUGIN_SERVER_ADDR", "INPUT_SERVER_ADDR"},
+				Value:   ":8080",
+			},
+			&cli.StringFlag{
+				Name:    "allow-origin",
+				Usage:   "origin allowed to call the api from a browser, e.g. https://example.com or *",
+				EnvVars: []string{"PLUGIN_SERVER_ALLOW_ORIGIN", "INPUT_SERVER_ALLOW_ORIGIN"},
+			},
+			&cli.IntFlag{
+				Name:    "workers",
+				Usage:   "number of jobs processed at once",
+				EnvVars: []string{"PLUGIN_SERVER_WORKERS", "INPUT_SERVER_WORKERS"},
+				Value:   1,
+			},
+			&cli.DurationFlag{
+				Name:    "job-ttl",
+				Usage:   "how long a finished job and its events are kept",
+				EnvVars: []string{"PLUGIN_SERVER_JOB_TTL", "INPUT_SERVER_JOB_TTL"},
+				Value:   time.Hour,
+			},
+		},
+	}
+}
+
+func serve(c *cli.Context) error {
+	cfg := newSetting(c)
+	cfg.Server = config.Server{
+		Addr:        c.String("addr"),
+		AllowOrigin: c.String("allow-origin"),
+		Workers:     c.Int("workers"),
+		JobTTL:      c.Duration("job-ttl"),
+	}
+	setupDebug(&cfg)
+
+	if err := resolveModel(&cfg); err != nil {
+		return err
+	}
+
+	// validate the shared options once before loading the model
+	check := cfg.Whisper
+	check.AudioPath = "-"
+	if err := check.Validate(); err != nil {
+		return err
+	}
+	if err := whisper.CheckFormats(cfg.Whisper.OutputFormat); err != nil {
+		return err
+	}
+
+	model, err := whisper.LoadModel(cfg.Whisper.Model)
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+
+	out, err := sink.New(cfg.Whisper.OutputFolder, &cfg.S3, cfg.Source.Insecure)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(c.Context, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// decode lets a single job use the shared model at a time
+	var decode sync.Mutex
+	return server.New(cfg, processInput,
+		whisper.WithSink(out),
+		whisper.WithModel(model),
+		whisper.WithLock(&decode),
+	).Run(ctx)
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/appleboy/go-whisper/whisper"
+)
+
+// Job statuses.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Event types pushed to the subscribers of a job.
+const (
+	EventProgress = "progress"
+	EventSegment  = "segment"
+	EventDone     = "done"
+)
+
+// subscriberBuffer is the number of events a slow subscriber may lag behind.
+const subscriberBuffer = 256
+
+// Event is a server-sent event of a job.
+type Event struct {
+	Type string
+	Data []byte
+}
+
+// Segment is the payload of a segment event.
+type Segment struct {
+	Start float64 `json:"start"` // Start is the time in seconds.
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Summary is the state of a job, also the payload of the done event.
+type Summary struct {
+	ID        string           `json:"id"`
+	Status    string           `json:"status"`
+	Progress  int              `json:"progress"`
+	Error     string           `json:"error,omitempty"`
+	Language  string           `json:"language,omitempty"`
+	Outputs   []whisper.Output `json:"outputs,omitempty"`
+	Segments  int              `json:"segments"`
+	CreatedAt time.Time        `json:"created_at"`
+	Duration  float64          `json:"duration,omitempty"` // Duration is the processing time in seconds.
+}
+
+// Job is a transcription requested through the API. It keeps the events, so
+// a client subscribing late still receives the whole transcript.
+type Job struct {
+	mu          sync.Mutex
+	summary     Summary
+	events      []Event
+	subscribers map[chan Event]struct{}
+	done        bool
+	finishedAt  time.Time
+}
+
+func newJob(id string) *Job {
+	return &Job{
+		summary: Summary{
+			ID:        id,
+			Status:    StatusQueued,
+			CreatedAt: time.Now(),
+		},
+		subscribers: map[chan Event]struct{}{},
+	}
+}
+
+// Summary returns the current state of the job.
+func (j *Job) Summary() Summary {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.summary
+}
+
+// Progress implements whisper.Listener.
+func (j *Job) Progress(progress int) {
+	j.mu.Lock()
+	j.summary.Progress = progress
+	j.mu.Unlock()
+
+	j.publish(EventProgress, map[string]int{"progress": progress})
+}
+
+// Segment implements whisper.Listener.
+func (j *Job) Segment(segment whisper.Segment) {
+	j.mu.Lock()
+	j.summary.Segments++
+	j.mu.Unlock()
+
+	j.publish(EventSegment, Segment{
+		Start: segment.Start.Seconds(),
+		End:   segment.End.Seconds(),
+		Text:  segment.Text,
+	})
+}
+
+func (j *Job) start() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.summary.Status = StatusRunning
+}
+
+// finish records the outcome, sends the done event and ends the subscriptions.
+func (j *Job) finish(e *whisper.Engine, err error) {
+	j.mu.Lock()
+	j.summary.Duration = time.Since(j.summary.CreatedAt).Seconds()
+	if err != nil {
+		j.summary.Status = StatusFailed
+		j.summary.Error = err.Error()
+	} else {
+		j.summary.Status = StatusCompleted
+		j.summary.Progress = 100
+		j.summary.Language = e.Language()
+		j.summary.Outputs = e.Outputs()
+	}
+	summary := j.summary
+	j.mu.Unlock()
+
+	j.publish(EventDone, summary)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.done = true
+	j.finishedAt = time.Now()
+	for ch := range j.subscribers {
+		close(ch)
+	}
+	j.subscribers = nil
+}
+
+// expired reports whether the job finished for longer than ttl.
+func (j *Job) expired(ttl time.Duration) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.done && time.Since(j.finishedAt) > ttl
+}
+
+func (j *Job) publish(typ string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	event := Event{Type: typ, Data: data}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.events = append(j.events, event)
+	for ch := range j.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// the client is too slow, it can reconnect and replay the events
+			close(ch)
+			delete(j.subscribers, ch)
+		}
+	}
+}
+
+// Subscribe returns the past events and a channel with the next ones.
+// The channel is closed once the job is done. Call the returned function
+// to unsubscribe.
+func (j *Job) Subscribe() ([]Event, <-chan Event, func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	history := append([]Event{}, j.events...)
+	ch := make(chan Event, subscriberBuffer)
+	if j.done {
+		close(ch)
+		return history, ch, func() {}
+	}
+
+	j.subscribers[ch] = struct{}{}
+	return history, ch, func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		if _, ok := j.subscribers[ch]; ok {
+			delete(j.subscribers, ch)
+			close(ch)
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/manifest"
+	"github.com/appleboy/go-whisper/metrics"
+	"github.com/appleboy/go-whisper/whisper"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxUpload is the largest audio file accepted by POST /jobs.
+const maxUpload = 2 << 30
+
+// queueSize is the number of jobs waiting for a worker before POST /jobs is rejected.
+const queueSize = 100
+
+// Runner downloads the input of the configuration and transcribes it.
+type Runner func(ctx context.Context, cfg *config.Setting, opts ...whisper.Option) (*whisper.Engine, error)
+
+// task is a queued job with its configuration.
+type task struct {
+	job     *Job
+	cfg     config.Setting
+	cleanup func()
+}
+
+// Server is the HTTP API. Jobs are queued and transcribed by the workers,
+// their progress and segments are pushed to the clients as server-sent events.
+type Server struct {
+	cfg    config.Setting
+	runner Runner
+	opts   []whisper.Option
+	queue  chan task
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// New creates the server. The options are passed to every job, e.g. the shared model.
+func New(cfg config.Setting, runner Runner, opts ...whisper.Option) *Server {
+	return &Server{
+		cfg:    cfg,
+		runner: runner,
+		opts:   opts,
+		queue:  make(chan task, queueSize),
+		jobs:   map[string]*Job{},
+	}
+}
+
+// Handler returns the routes of the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs", s.createJob)
+	mux.HandleFunc("GET /jobs/{id}", s.getJob)
+	mux.HandleFunc("GET /jobs/{id}/events", s.jobEvents)
+	mux.Handle("GET /metrics", metrics.Handler())
+
+	if s.cfg.Server.AllowOrigin == "" {
+		return mux
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", s.cfg.Server.AllowOrigin)
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// Run starts the workers and serves the API until the context is canceled.
+func (s *Server) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 0; i < max(s.cfg.Server.Workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+
+	srv := &http.Server{
+		Addr:              s.cfg.Server.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdown)
+	}()
+	log.Info().Str("addr", s.cfg.Server.Addr).Msg("listen for api requests")
+
+	err := srv.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	// the handlers may still queue jobs until the shutdown returns
+	<-stopped
+	wg.Wait()
+	s.drain()
+	return nil
+}
+
+// drain fails the jobs left in the queue once the workers stopped.
+func (s *Server) drain() {
+	for {
+		select {
+		case t := <-s.queue:
+			metrics.Queued(-1)
+			t.job.finish(nil, errors.New("server shut down before the job started"))
+			t.cleanup()
+		default:
+			return
+		}
+	}
+}
+
+// work transcribes the queued jobs until the context is canceled.
+func (s *Server) work(ctx context.Context) {
+	for {
+		select {
+		case t := <-s.queue:
+			metrics.Queued(-1)
+			s.process(ctx, t)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Server) process(ctx context.Context, t task) {
+	defer t.cleanup()
+
+	id := t.job.Summary().ID
+	logger := log.With().Str("job", id).Logger()
+	logger.Info().Msg("start job")
+
+	t.job.start()
+	opts := append(append([]whisper.Option{}, s.opts...), whisper.WithListener(t.job))
+	e, err := s.runner(logger.WithContext(ctx), &t.cfg, opts...)
+	t.job.finish(e, err)
+	if err != nil {
+		logger.Error().Err(err).Msg("job failed")
+		return
+	}
+	logger.Info().Msg("job completed")
+}
+
+// createJob queues a job from a JSON manifest item, or from a multipart form
+// with the audio in the file field and the overrides as form values.
+func (s *Server) createJob(w http.ResponseWriter, r *http.Request) {
+	var (
+		item    manifest.Item
+		cleanup = func() {}
+	)
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		path, dir, name, err := saveUpload(w, r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		cleanup = func() { os.RemoveAll(dir) }
+		item = manifest.Item{
+			Input:          path,
+			Language:       r.FormValue("language"),
+			Prompt:         r.FormValue("prompt"),
+			OutputFormat:   r.Form["output-format"],
+			OutputFilename: r.FormValue("output-filename"),
+			WebhookHeaders: r.Form["webhook-headers"],
+		}
+		if item.OutputFilename == "" {
+			item.OutputFilename = name
+		}
+	} else {
+		if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+		// clients must not read the files of the server
+		if !isRemote(item.Input) {
+			writeError(w, http.StatusBadRequest, errors.New("input must be an http(s), s3 or YouTube URL, upload local files as multipart form"))
+			return
+		}
+	}
+
+	// the webhook headers are the operator's, e.g. the Authorization header
+	if len(item.WebhookHeaders) > 0 {
+		cleanup()
+		writeError(w, http.StatusBadRequest, errors.New("webhook-headers can't be set through the API"))
+		return
+	}
+	if err := checkFilename(item.OutputFilename); err != nil {
+		cleanup()
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	cfg := item.Apply(s.cfg)
+	// the uploaded file is removed once the job is done, so keep the outputs in the working directory.
+	if cfg.Whisper.OutputFolder == "" {
+		cfg.Whisper.OutputFolder = "."
+	}
+	check := cfg.Whisper
+	if check.AudioPath == "" {
+		check.AudioPath = cfg.Youtube.URL
+	}
+	if err := check.Validate(); err != nil {
+		cleanup()
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := whisper.CheckFormats(check.OutputFormat); err != nil {
+		cleanup()
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.prune()
+
+	id := newID()
+	job := newJob(id)
+	s.mu.Lock()
+	s.jobs[id] = job
+	s.mu.Unlock()
+
+	metrics.Queued(1)
+	select {
+	case s.queue <- task{job: job, cfg: cfg, cleanup: cleanup}:
+	default:
+		metrics.Queued(-1)
+		s.mu.Lock()
+		delete(s.jobs, id)
+		s.mu.Unlock()
+		cleanup()
+		writeError(w, http.StatusServiceUnavailable, errors.New("too many queued jobs, retry later"))
+		return
+	}
+
+	w.Header().Set("Location", "/jobs/"+id)
+	writeJSON(w, http.StatusAccepted, job.Summary())
+}
+
+// prune forgets the jobs finished for longer than the job TTL.
+func (s *Server) prune() {
+	if s.cfg.Server.JobTTL <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, job := range s.jobs {
+		if job.expired(s.cfg.Server.JobTTL) {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+func (s *Server) job(w http.ResponseWriter, r *http.Request) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %s not found", r.PathValue("id")))
+		return nil
+	}
+	return job
+}
+
+func (s *Server) getJob(w http.ResponseWriter, r *http.Request) {
+	if job := s.job(w, r); job != nil {
+		writeJSON(w, http.StatusOK, job.Summary())
+	}
+}
+
+// jobEvents streams the events of the job as server-sent events, starting
+// with the past ones. The stream ends after the done event.
+func (s *Server) jobEvents(w http.ResponseWriter, r *http.Request) {
+	job := s.job(w, r)
+	if job == nil {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	history, events, unsubscribe := job.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range history {
+		writeEvent(w, event)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			writeEvent(w, event)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// saveUpload stores the file field of the form in a temporary directory.
+// The file is saved under a name of the server, the name sent by the client
+// is only returned for the outputs, empty if it isn't a valid file name.
+func saveUpload(w http.ResponseWriter, r *http.Request) (path, dir, name string, err error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUpload)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return "", "", "", fmt.Errorf("file is required: %w", err)
+	}
+	defer file.Close()
+
+	dir, err = os.MkdirTemp("", "go-whisper-upload")
+	if err != nil {
+		return "", "", "", err
+	}
+
+	path = filepath.Join(dir, "audio"+uploadExt(header.Filename))
+	out, err := os.Create(path)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", "", "", err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, file); err != nil {
+		os.RemoveAll(dir)
+		return "", "", "", err
+	}
+
+	name = strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename))
+	if checkFilename(name) != nil {
+		name = ""
+	}
+	return path, dir, name, nil
+}
+
+// uploadExt returns the extension of the uploaded file if it's only made of
+// letters and digits, ffmpeg probes the content anyway.
+func uploadExt(name string) string {
+	ext := filepath.Ext(name)
+	if len(ext) < 2 || len(ext) > 10 {
+		return ""
+	}
+	for _, r := range ext[1:] {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return strings.ToLower(ext)
+}
+
+// checkFilename returns an error if the output filename isn't a plain file
+// name, so the outputs stay in the output folder.
+func checkFilename(name string) error {
+	if name == "" {
+		return nil
+	}
+	if filepath.Base(name) != name || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid output-filename %q, use a file name without a folder", name)
+	}
+	return nil
+}
+
+// isRemote reports whether the input is downloaded rather than read from the disk.
+func isRemote(input string) bool {
+	if manifest.IsYoutube(input) {
+		return true
+	}
+	u, err := url.Parse(input)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "s3":
+		return u.Host != ""
+	}
+	return false
+}
+
+func writeEvent(w io.Writer, event Event) {
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, event.Data)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,338 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/whisper"
+)
+
+func TestJob_Subscribe(t *testing.T) {
+	job := newJob("a")
+	job.start()
+	job.Progress(10)
+
+	history, events, unsubscribe := job.Subscribe()
+	defer unsubscribe()
+	if len(history) != 1 || history[0].Type != EventProgress {
+		t.Fatalf("history = %v, want the progress event", history)
+	}
+
+	job.Segment(whisper.Segment{Start: time.Second, End: 2 * time.Second, Text: " hello"})
+	job.finish(nil, errors.New("boom"))
+
+	var got []string
+	for event := range events {
+		got = append(got, event.Type+" "+string(event.Data))
+	}
+	if len(got) != 2 ||
+		got[0] != `segment {"start":1,"end":2,"text":" hello"}` ||
+		!strings.HasPrefix(got[1], `done {"id":"a","status":"failed"`) {
+		t.Errorf("events = %q", got)
+	}
+
+	// a late subscriber replays every event
+	history, events, _ = job.Subscribe()
+	if len(history) != 3 {
+		t.Errorf("history = %d events, want 3", len(history))
+	}
+	if _, ok := <-events; ok {
+		t.Error("events of a finished job must be closed")
+	}
+
+	summary := job.Summary()
+	if summary.Status != StatusFailed || summary.Error != "boom" || summary.Segments != 1 || summary.Progress != 10 {
+		t.Errorf("summary = %+v", summary)
+	}
+}
+
+func testServer(runner Runner) *Server {
+	cfg := config.Setting{}
+	cfg.Whisper = config.Whisper{
+		Model:        "ggml-base.bin",
+		Language:     "auto",
+		Threads:      4,
+		OutputFormat: []string{"txt"},
+	}
+	return New(cfg, runner)
+}
+
+func TestServer_CreateJob(t *testing.T) {
+	upload := func(name string) (*bytes.Buffer, string) {
+		body := &bytes.Buffer{}
+		w := multipart.NewWriter(body)
+		if name != "" {
+			part, _ := w.CreateFormFile("file", name)
+			part.Write([]byte("RIFF"))
+		}
+		w.WriteField("language", "de")
+		w.Close()
+		return body, w.FormDataContentType()
+	}
+
+	tests := []struct {
+		name string
+		body func() (*bytes.Buffer, string)
+		want int
+	}{
+		{
+			name: "remote input",
+			body: func() (*bytes.Buffer, string) {
+				return bytes.NewBufferString(`{"input":"https://example.com/a.mp3"}`), "application/json"
+			},
+			want: http.StatusAccepted,
+		},
+		{
+			name: "youtube input",
+			body: func() (*bytes.Buffer, string) {
+				return bytes.NewBufferString(`{"input":"https://youtu.be/abc"}`), "application/json"
+			},
+			want: http.StatusAccepted,
+		},
+		{
+			name: "local path",
+			body: func() (*bytes.Buffer, string) {
+				return bytes.NewBufferString(`{"input":"/etc/passwd"}`), "application/json"
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "unsupported format",
+			body: func() (*bytes.Buffer, string) {
+				return bytes.NewBufferString(`{"input":"https://example.com/a.mp3","output-format":["doc"]}`), "application/json"
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "invalid json",
+			body: func() (*bytes.Buffer, string) {
+				return bytes.NewBufferString(`{`), "application/json"
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "upload",
+			body: func() (*bytes.Buffer, string) { return upload("meeting.wav") },
+			want: http.StatusAccepted,
+		},
+		{
+			name: "output filename outside the folder",
+			body: func() (*bytes.Buffer, string) {
+				return bytes.NewBufferString(`{"input":"https://example.com/a.mp3","output-filename":"../../etc/x"}`), "application/json"
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "webhook headers",
+			body: func() (*bytes.Buffer, string) {
+				return bytes.NewBufferString(`{"input":"https://example.com/a.mp3","webhook-headers":["Authorization=Bearer x"]}`), "application/json"
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "upload without file",
+			body: func() (*bytes.Buffer, string) { return upload("") },
+			want: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testServer(nil)
+			t.Cleanup(func() {
+				close(s.queue)
+				for task := range s.queue {
+					task.cleanup()
+				}
+			})
+			body, contentType := tt.body()
+			req := httptest.NewRequest(http.MethodPost, "/jobs", body)
+			req.Header.Set("Content-Type", contentType)
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if rec.Code != http.StatusAccepted {
+				return
+			}
+
+			var summary Summary
+			if err := json.NewDecoder(rec.Body).Decode(&summary); err != nil {
+				t.Fatal(err)
+			}
+			if summary.Status != StatusQueued || rec.Header().Get("Location") != "/jobs/"+summary.ID {
+				t.Errorf("summary = %+v, location = %s", summary, rec.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestServer_CreateJob_UploadName(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		want     string
+		output   string
+	}{
+		{name: "plain", filename: "meeting.wav", want: "audio.wav", output: "meeting"},
+		{name: "shell metacharacters", filename: "a;curl x|sh;.wav", want: "audio.wav", output: "a;curl x|sh;"},
+		{name: "odd extension", filename: "talk.$(id)", want: "audio", output: "talk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &bytes.Buffer{}
+			w := multipart.NewWriter(body)
+			part, _ := w.CreateFormFile("file", tt.filename)
+			part.Write([]byte("RIFF"))
+			w.Close()
+
+			s := testServer(nil)
+			req := httptest.NewRequest(http.MethodPost, "/jobs", body)
+			req.Header.Set("Content-Type", w.FormDataContentType())
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, req)
+			if rec.Code != http.StatusAccepted {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body)
+			}
+
+			task := <-s.queue
+			t.Cleanup(task.cleanup)
+			if got := filepath.Base(task.cfg.Whisper.AudioPath); got != tt.want {
+				t.Errorf("audio path = %s, want %s", got, tt.want)
+			}
+			if got := task.cfg.Whisper.OutputFilename; got != tt.output {
+				t.Errorf("output filename = %q, want %q", got, tt.output)
+			}
+		})
+	}
+}
+
+func TestServer_Drain(t *testing.T) {
+	s := testServer(nil)
+	var cleaned int
+	jobs := []*Job{newJob("a"), newJob("b")}
+	for _, job := range jobs {
+		s.queue <- task{job: job, cleanup: func() { cleaned++ }}
+	}
+
+	s.drain()
+
+	if len(s.queue) != 0 {
+		t.Errorf("queue = %d, want 0", len(s.queue))
+	}
+	if cleaned != len(jobs) {
+		t.Errorf("cleaned = %d, want %d", cleaned, len(jobs))
+	}
+	for _, job := range jobs {
+		history, _, _ := job.Subscribe()
+		if got := job.Summary().Status; got != StatusFailed {
+			t.Errorf("job %s status = %s, want %s", job.Summary().ID, got, StatusFailed)
+		}
+		if len(history) == 0 || history[len(history)-1].Type != EventDone {
+			t.Errorf("job %s events = %+v, want done", job.Summary().ID, history)
+		}
+	}
+}
+
+func TestServer_Events(t *testing.T) {
+	var got config.Setting
+	s := testServer(func(_ context.Context, cfg *config.Setting, _ ...whisper.Option) (*whisper.Engine, error) {
+		got = *cfg
+		if _, err := os.Stat(cfg.Whisper.AudioPath); err != nil {
+			t.Errorf("uploaded file: %v", err)
+		}
+		return nil, errors.New("decode failed")
+	})
+	s.cfg.Server.AllowOrigin = "*"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.work(ctx)
+
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	part, _ := w.CreateFormFile("file", "meeting.wav")
+	part.Write([]byte("RIFF"))
+	w.Close()
+
+	resp, err := http.Post(srv.URL+"/jobs", w.FormDataContentType(), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary Summary
+	json.NewDecoder(resp.Body).Decode(&summary)
+	resp.Body.Close()
+	if resp.Header.Get("Access-Control-Allow-Origin") != "*" {
+		t.Error("missing cors header")
+	}
+
+	resp, err = http.Get(srv.URL + "/jobs/" + summary.ID + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type = %s", ct)
+	}
+
+	// the stream ends after the done event
+	var events []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if event, ok := strings.CutPrefix(scanner.Text(), "event: "); ok {
+			events = append(events, event)
+		}
+	}
+	if len(events) != 1 || events[0] != EventDone {
+		t.Errorf("events = %v, want [done]", events)
+	}
+
+	if got.Whisper.OutputFilename != "meeting" || got.Whisper.OutputFolder != "." {
+		t.Errorf("output = %q in %q, want meeting in .", got.Whisper.OutputFilename, got.Whisper.OutputFolder)
+	}
+	// the upload is removed right after the done event
+	removed := false
+	for i := 0; i < 100 && !removed; i++ {
+		_, err := os.Stat(got.Whisper.AudioPath)
+		removed = os.IsNotExist(err)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !removed {
+		t.Error("uploaded file must be removed once the job is done")
+	}
+
+	resp, err = http.Get(srv.URL + "/jobs/" + summary.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	json.NewDecoder(resp.Body).Decode(&summary)
+	if summary.Status != StatusFailed || summary.Error != "decode failed" {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	resp, err = http.Get(srv.URL + "/jobs/unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,99 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/source"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// S3 uploads the transcripts to an S3-compatible object storage.
+type S3 struct {
+	client *minio.Client
+	bucket string
+	prefix string
+	sse    encrypt.ServerSide
+}
+
+// NewS3 creates a new sink for an s3://bucket/prefix URL. insecure skips
+// the TLS verification of the endpoint.
+func NewS3(u *url.URL, cfg *config.S3, insecure bool) (*S3, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 url %q, expected s3://bucket/prefix", u.String())
+	}
+
+	sse, err := parseSSE(cfg.SSE)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := source.NewS3Client(cfg, insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3{
+		client: client,
+		bucket: u.Host,
+		prefix: strings.Trim(u.Path, "/"),
+		sse:    sse,
+	}, nil
+}
+
+// Write uploads the data under the prefix and returns the object URL.
+// Only the base name of the output path is used as the object name.
+func (s *S3) Write(ctx context.Context, name string, data []byte) (string, error) {
+	key := path.Join(s.prefix, path.Base(name))
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+
+	_, err := s.client.PutObject(
+		ctx,
+		s.bucket,
+		key,
+		bytes.NewReader(data),
+		int64(len(data)),
+		minio.PutObjectOptions{
+			ContentType:          contentType,
+			ServerSideEncryption: s.sse,
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return s.objectURL(key), nil
+}
+
+// objectURL returns the path-style URL of the object.
+func (s *S3) objectURL(key string) string {
+	u := *s.client.EndpointURL()
+	u.Path = "/" + path.Join(s.bucket, key)
+	return u.String()
+}
+
+// parseSSE converts the SSE setting, s3 or kms:<key-id>, to the encryption header.
+func parseSSE(v string) (encrypt.ServerSide, error) {
+	kind, keyID, _ := strings.Cut(v, ":")
+	switch strings.ToLower(kind) {
+	case "":
+		return nil, nil
+	case "s3", "aes256":
+		return encrypt.NewSSE(), nil
+	case "kms":
+		return encrypt.NewSSEKMS(keyID, nil)
+	}
+
+	return nil, fmt.Errorf("unsupported server-side encryption: %s", v)
+}
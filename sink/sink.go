@@ -0,0 +1,77 @@
+package sink
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/appleboy/go-whisper/config"
+)
+
+// Stdout is the output folder used to write the transcripts to standard output.
+const Stdout = "-"
+
+// Sink stores the rendered transcripts.
+type Sink interface {
+	// Write stores the data for the given output path and returns its location.
+	Write(ctx context.Context, name string, data []byte) (string, error)
+}
+
+// New returns the sink selected by the scheme of the given output folder.
+// It returns the local sink if the output folder is a local path. insecure
+// skips the TLS verification of the S3 endpoint.
+func New(folder string, cfg *config.S3, insecure bool) (Sink, error) {
+	if folder == Stdout {
+		return NewWriter(os.Stdout), nil
+	}
+
+	if u, err := url.Parse(folder); err == nil && strings.EqualFold(u.Scheme, "s3") {
+		s, err := NewS3(u, cfg, insecure)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	return NewLocal(), nil
+}
+
+// Local writes the transcripts to the local filesystem.
+type Local struct{}
+
+// NewLocal creates a new local sink.
+func NewLocal() *Local {
+	return &Local{}
+}
+
+// Write writes the data to the output path.
+func (l *Local) Write(_ context.Context, name string, data []byte) (string, error) {
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// Writer writes the transcripts to a stream such as standard output.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter creates a new sink for the given writer.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w: w,
+	}
+}
+
+// Write writes the data to the stream.
+func (s *Writer) Write(_ context.Context, _ string, data []byte) (string, error) {
+	if _, err := s.w.Write(data); err != nil {
+		return "", err
+	}
+
+	return Stdout, nil
+}
@@ -0,0 +1,198 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/appleboy/go-whisper/config"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		folder  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "default folder",
+			folder: "",
+			want:   "*sink.Local",
+		},
+		{
+			name:   "local folder",
+			folder: "/app/output",
+			want:   "*sink.Local",
+		},
+		{
+			name:   "stdout",
+			folder: "-",
+			want:   "*sink.Writer",
+		},
+		{
+			name:   "s3 bucket with prefix",
+			folder: "s3://bucket/transcripts",
+			want:   "*sink.S3",
+		},
+		{
+			name:    "unsupported sse",
+			folder:  "s3://bucket/transcripts",
+			want:    "<nil>",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.S3{Region: "us-east-1"}
+			if tt.wantErr {
+				cfg.SSE = "unknown"
+			}
+			got, err := New(tt.folder, cfg, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if name := fmt.Sprintf("%T", got); name != tt.want {
+				t.Errorf("New() = %v, want %v", name, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocal_Write(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "jfk.txt")
+	got, err := NewLocal().Write(context.Background(), name, []byte("go-whisper"))
+	if err != nil {
+		t.Fatalf("Local.Write() error = %v", err)
+	}
+	if got != name {
+		t.Errorf("Local.Write() = %v, want %v", got, name)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "go-whisper" {
+		t.Errorf("Local.Write() content = %s, want go-whisper", data)
+	}
+}
+
+func TestWriter_Write(t *testing.T) {
+	var buf bytes.Buffer
+	got, err := NewWriter(&buf).Write(context.Background(), "/tmp/jfk.txt", []byte("go-whisper"))
+	if err != nil {
+		t.Fatalf("Writer.Write() error = %v", err)
+	}
+	if got != Stdout {
+		t.Errorf("Writer.Write() = %v, want %v", got, Stdout)
+	}
+	if buf.String() != "go-whisper" {
+		t.Errorf("Writer.Write() content = %s, want go-whisper", buf.String())
+	}
+}
+
+func TestS3_Write(t *testing.T) {
+	var (
+		gotPath string
+		gotSSE  string
+		gotBody []byte
+	)
+
+	// minio stand-in accepting a single object upload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		gotPath = r.URL.Path
+		gotSSE = r.Header.Get("X-Amz-Server-Side-Encryption")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("ETag", `"5bde242e0b602bafb2a2422553437e69"`)
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse("s3://media/transcripts/")
+	s, err := NewS3(u, &config.S3{
+		Endpoint:  srv.URL,
+		Region:    "us-east-1",
+		AccessKey: "minioadmin",
+		SecretKey: "minioadmin",
+		PathStyle: true,
+		SSE:       "s3",
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Write(context.Background(), "/tmp/whisper/jfk.srt", []byte("go-whisper"))
+	if err != nil {
+		t.Fatalf("S3.Write() error = %v", err)
+	}
+
+	if want := srv.URL + "/media/transcripts/jfk.srt"; got != want {
+		t.Errorf("S3.Write() = %v, want %v", got, want)
+	}
+	if gotPath != "/media/transcripts/jfk.srt" {
+		t.Errorf("S3.Write() path = %v, want /media/transcripts/jfk.srt", gotPath)
+	}
+	if gotSSE != "AES256" {
+		t.Errorf("S3.Write() sse = %v, want AES256", gotSSE)
+	}
+	if !bytes.Contains(gotBody, []byte("go-whisper")) {
+		t.Errorf("S3.Write() body = %s, want go-whisper", gotBody)
+	}
+}
+
+func TestS3_Insecure(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"5bde242e0b602bafb2a2422553437e69"`)
+	}))
+	// the rejected handshakes are expected
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		insecure bool
+		wantErr  bool
+	}{
+		{
+			name:     "skip verification",
+			insecure: true,
+		},
+		{
+			name:    "self-signed certificate",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse("s3://media/transcripts/")
+			s, err := NewS3(u, &config.S3{
+				Endpoint:  srv.URL,
+				Region:    "us-east-1",
+				AccessKey: "minioadmin",
+				SecretKey: "minioadmin",
+				PathStyle: true,
+			}, tt.insecure)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = s.Write(context.Background(), "jfk.srt", []byte("go-whisper"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("S3.Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package source
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+// ChecksumError is returned when a downloaded file doesn't match the expected digest.
+type ChecksumError struct {
+	Path     string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s, expected: %s, actual: %s", e.Path, e.Expected, e.Actual)
+}
+
+// newHash returns the hash for the given algorithm name.
+func newHash(algo string) (hash.Hash, error) {
+	switch strings.ToLower(algo) {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported checksum algorithm: %s", algo)
+}
+
+// parseChecksum splits a checksum in the form algo:hex.
+func parseChecksum(sum string) (string, string, error) {
+	algo, digest, ok := strings.Cut(sum, ":")
+	if !ok || digest == "" {
+		return "", "", fmt.Errorf("invalid checksum %q, expected algo:hex", sum)
+	}
+
+	return strings.ToLower(algo), strings.ToLower(digest), nil
+}
+
+// FileChecksum returns the hex digest of the file with the given algorithm.
+func FileChecksum(name, algo string) (string, error) {
+	h, err := newHash(algo)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyChecksum checks the file against a checksum in the form algo:hex.
+// An empty checksum always passes.
+func VerifyChecksum(name, sum string) error {
+	if sum == "" {
+		return nil
+	}
+
+	algo, expected, err := parseChecksum(sum)
+	if err != nil {
+		return err
+	}
+
+	actual, err := FileChecksum(name, algo)
+	if err != nil {
+		return err
+	}
+
+	if actual != expected {
+		return &ChecksumError{
+			Path:     name,
+			Expected: expected,
+			Actual:   actual,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/appleboy/go-whisper/config"
+
+	"github.com/rs/zerolog/log"
+)
+
+// HTTP downloads media from a plain HTTP(S) URL.
+// Interrupted downloads are resumed with a range request on the next attempt.
+type HTTP struct {
+	cfg    *config.Source
+	url    string
+	client *http.Client
+	folder string
+}
+
+// NewHTTP creates a new HTTP(S) source.
+func NewHTTP(s string, cfg *config.Source) *HTTP {
+	return &HTTP{
+		cfg: cfg,
+		url: s,
+		client: &http.Client{
+			Transport: NewTransport(cfg.Insecure),
+		},
+	}
+}
+
+// Filename returns the last element of the URL path.
+func (h *HTTP) Filename() string {
+	u, err := url.Parse(h.url)
+	if err != nil {
+		return ""
+	}
+
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		return ""
+	}
+
+	return name
+}
+
+// Fetch downloads the media to a temporary folder and verifies the checksum.
+func (h *HTTP) Fetch(ctx context.Context) (string, error) {
+	folder, err := os.MkdirTemp("", "source")
+	if err != nil {
+		return "", err
+	}
+	h.folder = folder
+
+	output := filepath.Join(folder, tempName(h.Filename()))
+
+	err = Retry(ctx, h.cfg.Retry, func() error {
+		if err := Download(ctx, h.client, h.url, output); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("phase", "download").Str("url", config.RedactURL(h.url)).Msg("download failed")
+			return err
+		}
+
+		if err := VerifyChecksum(output, h.cfg.Checksum); err != nil {
+			// start from scratch on the next attempt
+			_ = os.Remove(output)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return output, nil
+}
+
+// Close removes the downloaded file.
+func (h *HTTP) Close() error {
+	if h.folder == "" {
+		return nil
+	}
+
+	return os.RemoveAll(h.folder)
+}
+
+// Download fetches the URL into dst.
+// If dst already has content, only the remaining bytes are requested.
+func Download(ctx context.Context, client *http.Client, s, dst string) error {
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	offset := info.Size()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return config.RedactError(err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		// the server ignored the range request, so start over
+		offset = 0
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+	case http.StatusPartialContent:
+	case http.StatusRequestedRangeNotSatisfiable:
+		if offset > 0 {
+			// the file was already complete
+			return nil
+		}
+		fallthrough
+	default:
+		return fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	n, err := io.Copy(f, res.Body)
+	if err != nil {
+		return err
+	}
+	if res.ContentLength >= 0 && n != res.ContentLength {
+		return errors.New("download is incomplete")
+	}
+
+	return nil
+}
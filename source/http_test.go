@@ -0,0 +1,136 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+)
+
+func TestDownload(t *testing.T) {
+	content := []byte(strings.Repeat("go-whisper", 100))
+
+	type args struct {
+		partial []byte
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantRange string
+	}{
+		{
+			name:      "download from scratch",
+			args:      args{},
+			wantRange: "",
+		},
+		{
+			name: "resume partial download",
+			args: args{
+				partial: content[:300],
+			},
+			wantRange: "bytes=300-",
+		},
+		{
+			name: "file already complete",
+			args: args{
+				partial: content,
+			},
+			wantRange: "bytes=1000-",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRange := ""
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotRange = r.Header.Get("Range")
+				http.ServeContent(w, r, "audio.wav", time.Time{}, bytes.NewReader(content))
+			}))
+			defer srv.Close()
+
+			dst := filepath.Join(t.TempDir(), "audio.wav")
+			if tt.args.partial != nil {
+				if err := os.WriteFile(dst, tt.args.partial, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := Download(context.Background(), srv.Client(), srv.URL+"/audio.wav", dst); err != nil {
+				t.Fatalf("Download() error = %v", err)
+			}
+			if gotRange != tt.wantRange {
+				t.Errorf("Download() range = %v, want %v", gotRange, tt.wantRange)
+			}
+
+			got, err := os.ReadFile(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("Download() content length = %d, want %d", len(got), len(content))
+			}
+		})
+	}
+}
+
+func TestHTTP_Fetch(t *testing.T) {
+	content := []byte("go-whisper")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "jfk.wav", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		checksum string
+		wantErr  bool
+	}{
+		{
+			name: "without checksum",
+		},
+		{
+			name:     "matching sha256 checksum",
+			checksum: "sha256:938407f408a76ba995b43ef7a0b4e0b4552ca25f524f57ffc570f0a972f2744c",
+		},
+		{
+			name:     "matching md5 checksum",
+			checksum: "MD5:5BDE242E0B602BAFB2A2422553437E69",
+		},
+		{
+			name:     "checksum mismatch",
+			checksum: "sha1:6d0a1f1ac5b2d6a2b3dc8e9ab6b9a9e4b77e1bdd",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTP(srv.URL+"/media/jfk.wav", &config.Source{
+				Retry:    1,
+				Checksum: tt.checksum,
+			})
+			defer h.Close()
+
+			got, err := h.Fetch(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HTTP.Fetch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				var checksumErr *ChecksumError
+				if !errors.As(err, &checksumErr) {
+					t.Errorf("HTTP.Fetch() error = %v, want ChecksumError", err)
+				}
+				return
+			}
+			if filepath.Base(got) != "input.wav" {
+				t.Errorf("HTTP.Fetch() = %v, want input.wav", got)
+			}
+		})
+	}
+}
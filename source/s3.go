@@ -0,0 +1,133 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/appleboy/go-whisper/config"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/rs/zerolog/log"
+)
+
+// S3 downloads media from an S3-compatible object storage.
+type S3 struct {
+	cfg    *config.Source
+	client *minio.Client
+	bucket string
+	key    string
+	folder string
+}
+
+// NewS3 creates a new source for an s3://bucket/key URL.
+func NewS3(u *url.URL, cfg *config.Source, s3 *config.S3) (*S3, error) {
+	bucket := u.Host
+	key := strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid s3 url %q, expected s3://bucket/key", u.String())
+	}
+
+	client, err := NewS3Client(s3, cfg.Insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3{
+		cfg:    cfg,
+		client: client,
+		bucket: bucket,
+		key:    key,
+	}, nil
+}
+
+// NewS3Client creates a client for the configured S3-compatible endpoint.
+// Static keys are used if present, otherwise the credentials are read
+// from the environment, the AWS credentials file or the instance role.
+func NewS3Client(cfg *config.S3, insecure bool) (*minio.Client, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3.amazonaws.com"
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, "")
+	if cfg.AccessKey == "" {
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.EnvMinio{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{},
+		})
+	}
+
+	lookup := minio.BucketLookupAuto
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	return minio.New(u.Host, &minio.Options{
+		Creds:        creds,
+		Secure:       u.Scheme == "https",
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+		Transport:    NewTransport(insecure),
+	})
+}
+
+// Filename returns the last element of the object key.
+func (s *S3) Filename() string {
+	return path.Base(s.key)
+}
+
+// Fetch downloads the object to a temporary folder and verifies the checksum.
+// Partially downloaded objects are resumed on the next attempt.
+func (s *S3) Fetch(ctx context.Context) (string, error) {
+	folder, err := os.MkdirTemp("", "source")
+	if err != nil {
+		return "", err
+	}
+	s.folder = folder
+
+	output := filepath.Join(folder, tempName(s.Filename()))
+
+	err = Retry(ctx, s.cfg.Retry, func() error {
+		if err := s.client.FGetObject(ctx, s.bucket, s.key, output, minio.GetObjectOptions{}); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("phase", "download").Str("bucket", s.bucket).Str("key", s.key).Msg("download failed")
+			return err
+		}
+
+		if err := VerifyChecksum(output, s.cfg.Checksum); err != nil {
+			_ = os.Remove(output)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return output, nil
+}
+
+// Close removes the downloaded file.
+func (s *S3) Close() error {
+	if s.folder == "" {
+		return nil
+	}
+
+	return os.RemoveAll(s.folder)
+}
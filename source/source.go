@@ -0,0 +1,141 @@
+package source
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Stdin is the audio path used to read media from standard input.
+const Stdin = "-"
+
+// Source is a remote media input that is fetched to a local file before transcribing.
+type Source interface {
+	// Fetch downloads the media and returns the local file path.
+	Fetch(ctx context.Context) (string, error)
+	// Filename returns the original name of the media.
+	Filename() string
+	// Close removes the temporary files created by Fetch.
+	Close() error
+}
+
+// New returns the source selected by the scheme of the given audio path.
+// It returns nil if the audio path is a local file.
+func New(audioPath string, cfg *config.Source, s3 *config.S3) (Source, error) {
+	if audioPath == Stdin {
+		return NewStdin(cfg), nil
+	}
+
+	u, err := url.Parse(audioPath)
+	if err != nil {
+		return nil, nil
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return NewHTTP(audioPath, cfg), nil
+	case "s3":
+		s, err := NewS3(u, cfg, s3)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	return nil, nil
+}
+
+// Remote reports whether the audio path is fetched by a source before
+// transcribing, i.e. it's standard input, an http(s) or an s3 URL.
+func Remote(audioPath string) bool {
+	if audioPath == Stdin {
+		return true
+	}
+	u, err := url.Parse(audioPath)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "s3":
+		return true
+	}
+	return false
+}
+
+// tempName returns the name of a download in its temporary folder: input
+// with the extension of the original name if it's only letters and digits.
+// The original name, e.g. a URL path or an object key, is only used to name
+// the outputs.
+func tempName(name string) string {
+	ext := path.Ext(name)
+	if len(ext) < 2 || len(ext) > 10 {
+		return "input"
+	}
+	for _, r := range ext[1:] {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return "input"
+		}
+	}
+	return "input" + strings.ToLower(ext)
+}
+
+// NewTransport returns the http transport shared by all downloaders.
+// The proxy is read from the environment and TLS verification is skipped if insecure is true.
+func NewTransport(insecure bool) *http.Transport {
+	proxyFunc := httpproxy.FromEnvironment().ProxyFunc()
+	httpTransport := &http.Transport{
+		Proxy: func(r *http.Request) (uri *url.URL, err error) {
+			return proxyFunc(r.URL)
+		},
+		IdleConnTimeout:       60 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		ForceAttemptHTTP2:     true,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+	}
+
+	if insecure {
+		httpTransport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: true,
+		}
+	}
+
+	return httpTransport
+}
+
+// Retry calls fn up to count times until it succeeds, waiting one second between attempts.
+func Retry(ctx context.Context, count int, fn func() error) error {
+	if count < 1 {
+		count = 1
+	}
+
+	var err error
+	for i := 0; i < count; i++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if i == count-1 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(1 * time.Second):
+		}
+	}
+
+	return err
+}
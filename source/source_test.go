@@ -0,0 +1,143 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		audioPath string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "local file",
+			audioPath: "/app/testdata/jfk.wav",
+			want:      "<nil>",
+		},
+		{
+			name:      "stdin",
+			audioPath: "-",
+			want:      "*source.Reader",
+		},
+		{
+			name:      "http url",
+			audioPath: "http://example.com/jfk.wav",
+			want:      "*source.HTTP",
+		},
+		{
+			name:      "https url",
+			audioPath: "https://example.com/jfk.wav",
+			want:      "*source.HTTP",
+		},
+		{
+			name:      "s3 url",
+			audioPath: "s3://bucket/path/jfk.wav",
+			want:      "*source.S3",
+		},
+		{
+			name:      "s3 url without key",
+			audioPath: "s3://bucket",
+			want:      "<nil>",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.audioPath, &config.Source{}, &config.S3{Region: "us-east-1"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if name := fmt.Sprintf("%T", got); name != tt.want {
+				t.Errorf("New() = %v, want %v", name, tt.want)
+			}
+			if remote := tt.want != "<nil>" || tt.wantErr; Remote(tt.audioPath) != remote {
+				t.Errorf("Remote() = %v, want %v", !remote, remote)
+			}
+		})
+	}
+}
+
+func TestS3_Fetch(t *testing.T) {
+	content := []byte("go-whisper")
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// minio stand-in serving a single object with path-style addressing
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/media/calls/jfk.wav" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", `"5bde242e0b602bafb2a2422553437e69"`)
+		w.Header().Set("Last-Modified", modTime.Format(http.TimeFormat))
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			return
+		}
+		http.ServeContent(w, r, "jfk.wav", modTime, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	src, err := New("s3://media/calls/jfk.wav", &config.Source{
+		Retry:    1,
+		Checksum: "md5:5bde242e0b602bafb2a2422553437e69",
+	}, &config.S3{
+		Endpoint:  srv.URL,
+		Region:    "us-east-1",
+		AccessKey: "minioadmin",
+		SecretKey: "minioadmin",
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	got, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("S3.Fetch() error = %v", err)
+	}
+	if src.Filename() != "jfk.wav" {
+		t.Errorf("S3.Filename() = %v, want jfk.wav", src.Filename())
+	}
+
+	data, err := os.ReadFile(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Errorf("S3.Fetch() content = %s, want %s", data, content)
+	}
+}
+
+func TestTempName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "My Podcast.mp3", want: "input.mp3"},
+		{name: "a;curl x|sh;.WAV", want: "input.wav"},
+		{name: "$(id).m4a", want: "input.m4a"},
+		{name: "talk.$(id)", want: "input"},
+		{name: "stdin", want: "input"},
+		{name: "", want: "input"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tempName(tt.name); got != tt.want {
+				t.Errorf("tempName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package source
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/appleboy/go-whisper/config"
+)
+
+// Reader reads media from a stream such as standard input.
+type Reader struct {
+	cfg    *config.Source
+	r      io.Reader
+	folder string
+}
+
+// NewStdin creates a new source that reads media from standard input.
+func NewStdin(cfg *config.Source) *Reader {
+	return &Reader{
+		cfg: cfg,
+		r:   os.Stdin,
+	}
+}
+
+// Filename returns the name used for the output files.
+func (s *Reader) Filename() string {
+	return "stdin"
+}
+
+// Fetch copies the stream to a temporary file and verifies the checksum.
+func (s *Reader) Fetch(_ context.Context) (string, error) {
+	folder, err := os.MkdirTemp("", "source")
+	if err != nil {
+		return "", err
+	}
+	s.folder = folder
+
+	output := filepath.Join(folder, tempName(s.Filename()))
+	f, err := os.Create(output)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, s.r); err != nil {
+		return "", err
+	}
+
+	if err := VerifyChecksum(output, s.cfg.Checksum); err != nil {
+		return "", err
+	}
+
+	return output, nil
+}
+
+// Close removes the temporary file.
+func (s *Reader) Close() error {
+	if s.folder == "" {
+		return nil
+	}
+
+	return os.RemoveAll(s.folder)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/whisper"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/net/websocket"
+)
+
+func streamCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "stream",
+		Usage:  "transcribe live audio and print partial and final segments as JSON lines",
+		Action: stream,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "listen",
+				Usage:   "accept streams on tcp://host:port or ws://host:port/path instead of stdin",
+				EnvVars: []string{"PLUGIN_STREAM_LISTEN", "INPUT_STREAM_LISTEN"},
+			},
+			&cli.StringFlag{
+				Name:    "input-format",
+				Usage:   "input encoding: s16le, f32le (16 kHz mono) or auto to decode any format with ffmpeg",
+				EnvVars: []string{"PLUGIN_STREAM_INPUT_FORMAT", "INPUT_STREAM_INPUT_FORMAT"},
+				Value:   whisper.FormatS16LE,
+			},
+			&cli.DurationFlag{
+				Name:    "step",
+				Usage:   "how often the pending audio is decoded",
+				EnvVars: []string{"PLUGIN_STREAM_STEP", "INPUT_STREAM_STEP"},
+				Value:   time.Second,
+			},
+			&cli.DurationFlag{
+				Name:    "window",
+				Usage:   "longest audio decoded before a final segment is forced, up to 30s",
+				EnvVars: []string{"PLUGIN_STREAM_WINDOW", "INPUT_STREAM_WINDOW"},
+				Value:   20 * time.Second,
+			},
+		},
+	}
+}
+
+func stream(c *cli.Context) error {
+	cfg := newSetting(c)
+	cfg.Stream = config.Stream{
+		Listen: c.String("listen"),
+		Format: c.String("input-format"),
+		Step:   c.Duration("step"),
+		Window: c.Duration("window"),
+	}
+	setupDebug(&cfg)
+
+	if err := resolveModel(&cfg); err != nil {
+		return err
+	}
+
+	if cfg.Stream.Step <= 0 {
+		return errors.New("step must be greater than 0")
+	}
+	if cfg.Stream.Window > 30*time.Second {
+		return fmt.Errorf("window must not exceed 30s, got %s", cfg.Stream.Window)
+	}
+
+	// the audio path isn't used, the audio is read from the stream
+	check := cfg.Whisper
+	check.AudioPath = "-"
+	if err := check.Validate(); err != nil {
+		return err
+	}
+
+	model, err := whisper.LoadModel(cfg.Whisper.Model)
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+
+	ctx, stop := signal.NotifyContext(c.Context, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	s := &streamServer{cfg: &cfg, model: model}
+	if cfg.Stream.Listen == "" {
+		return s.serve(ctx, os.Stdin, os.Stdout)
+	}
+
+	u, err := url.Parse(cfg.Stream.Listen)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "tcp":
+		return s.listenTCP(ctx, u.Host)
+	case "ws":
+		return s.listenWebSocket(ctx, u.Host, u.Path)
+	}
+
+	return fmt.Errorf("unsupported listen address %s, use tcp://host:port or ws://host:port/path", cfg.Stream.Listen)
+}
+
+// streamServer transcribes concurrent streams with a shared model.
+type streamServer struct {
+	cfg   *config.Setting
+	model whisper.Model
+	// decode lets a single stream use the model at a time
+	decode sync.Mutex
+}
+
+// serve transcribes the audio of r and writes the events to w as JSON lines.
+func (s *streamServer) serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	st := whisper.NewStream(&s.cfg.Whisper, &s.cfg.Stream, s.model, &s.decode, func(e whisper.Event) error {
+		return enc.Encode(e)
+	})
+
+	return st.Run(ctx, r)
+}
+
+func (s *streamServer) listenTCP(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	log.Info().Str("addr", ln.Addr().String()).Msg("listen for tcp streams")
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		go func() {
+			defer conn.Close()
+			s.handle(ctx, conn.RemoteAddr().String(), conn, conn)
+		}()
+	}
+}
+
+func (s *streamServer) listenWebSocket(ctx context.Context, addr, path string) error {
+	if path == "" {
+		path = "/"
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, websocket.Handler(func(ws *websocket.Conn) {
+		// binary frames carry the audio, every event is sent as a text frame
+		ws.PayloadType = websocket.TextFrame
+		s.handle(ws.Request().Context(), ws.Request().RemoteAddr, ws, ws)
+	}))
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	log.Info().Str("addr", addr).Str("path", path).Msg("listen for websocket streams")
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// handle transcribes a single client connection.
+func (s *streamServer) handle(ctx context.Context, remote string, r io.Reader, w io.Writer) {
+	logger := log.With().Str("remote", remote).Logger()
+	logger.Info().Msg("stream connected")
+
+	if err := s.serve(ctx, r, w); err != nil && !errors.Is(err, context.Canceled) {
+		logger.Error().Err(err).Msg("stream error")
+		return
+	}
+	logger.Info().Msg("stream closed")
+}
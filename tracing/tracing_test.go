@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/appleboy/go-whisper/config"
+)
+
+func TestSetup(t *testing.T) {
+	// the collector stand-in records the exported batches
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+		if len(body) == 0 {
+			t.Error("empty export request")
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	shutdown, err := Setup(context.Background(), &config.Tracing{Endpoint: collector.URL + "/v1/traces"}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, parent := Start(context.Background(), "job")
+	_, child := Start(ctx, "whisper.process")
+	End(child, errors.New("boom"))
+	End(parent, nil)
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) == 0 || requests[0] != "/v1/traces" {
+		t.Errorf("collector requests = %v, want an export to /v1/traces", requests)
+	}
+}
+
+func TestSetup_Disabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), &config.Tracing{}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() = %v", err)
+	}
+}
@@ -0,0 +1,226 @@
+// Package transcript reads the json, srt, vtt and csv transcripts written
+// by go-whisper. It doesn't depend on the whisper.cpp bindings, so tools
+// like eval build without cgo.
+package transcript
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Segment is a timed text of a transcript.
+type Segment struct {
+	Num    int
+	Start  time.Duration
+	End    time.Duration
+	Text   string
+	Tokens []Token
+}
+
+// Token is a token of a segment, only the json transcripts have them.
+type Token struct {
+	ID    int
+	Text  string
+	P     float32
+	Start time.Duration
+	End   time.Duration
+}
+
+// JSON is the json transcript, the times are in seconds.
+type JSON struct {
+	Segments    []JSONSegment    `json:"segments"`
+	Corrections []JSONCorrection `json:"corrections,omitempty"`
+}
+
+// JSONSegment is a segment of the json transcript.
+type JSONSegment struct {
+	ID     int         `json:"id"`
+	Start  float64     `json:"start"`
+	End    float64     `json:"end"`
+	Text   string      `json:"text"`
+	Tokens []JSONToken `json:"tokens,omitempty"`
+}
+
+// JSONCorrection is a glossary substitution in the segment of the given time.
+type JSONCorrection struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	From  string  `json:"from"`
+	To    string  `json:"to"`
+}
+
+// JSONToken is a token of a json segment.
+type JSONToken struct {
+	ID    int     `json:"id"`
+	Text  string  `json:"text"`
+	P     float32 `json:"p"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Read reads the segments of a transcript written in the json, srt, vtt or
+// csv format, selected by the file extension.
+func Read(name string) ([]Segment, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var segments []Segment
+	switch format := strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), "."); format {
+	case "json":
+		segments, err = parseJSON(f)
+	case "srt", "vtt":
+		segments, err = parseCues(f)
+	case "csv":
+		segments, err = parseCSV(f)
+	case "txt":
+		return nil, fmt.Errorf("%s has no timestamps, convert a json, srt, vtt or csv transcript", name)
+	default:
+		return nil, fmt.Errorf("unsupported transcript format %q, supported formats: json, srt, vtt, csv", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+
+	return segments, nil
+}
+
+func parseJSON(r io.Reader) ([]Segment, error) {
+	var doc JSON
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	segments := make([]Segment, 0, len(doc.Segments))
+	for i, s := range doc.Segments {
+		segment := Segment{
+			Num:   i,
+			Start: seconds(s.Start),
+			End:   seconds(s.End),
+			Text:  s.Text,
+		}
+		for _, t := range s.Tokens {
+			segment.Tokens = append(segment.Tokens, Token{
+				ID:    t.ID,
+				Text:  t.Text,
+				P:     t.P,
+				Start: seconds(t.Start),
+				End:   seconds(t.End),
+			})
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// parseCues reads the cues of srt and WebVTT subtitles. The cue numbers,
+// headers, notes and cue settings are skipped.
+func parseCues(r io.Reader) ([]Segment, error) {
+	var (
+		segments []Segment
+		current  *Segment
+		lines    []string
+	)
+	flush := func() {
+		if current != nil {
+			current.Text = " " + strings.Join(lines, " ")
+			segments = append(segments, *current)
+		}
+		current, lines = nil, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		switch {
+		case line == "":
+			flush()
+		case strings.Contains(line, "-->"):
+			flush()
+			from, to, _ := strings.Cut(line, "-->")
+			fields := strings.Fields(to)
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("line %d: missing end time", n)
+			}
+			start, err := parseTimestamp(strings.TrimSpace(from))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			end, err := parseTimestamp(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			current = &Segment{Num: len(segments), Start: start, End: end}
+		case current != nil:
+			lines = append(lines, line)
+		}
+	}
+	flush()
+
+	return segments, scanner.Err()
+}
+
+func parseCSV(r io.Reader) ([]Segment, error) {
+	reader := csv.NewReader(r)
+	// the text isn't escaped by the csv output
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = 3
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []Segment
+	for i, record := range records {
+		if i == 0 && record[0] == "start" {
+			continue
+		}
+		start, err := time.ParseDuration(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		end, err := time.ParseDuration(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		segments = append(segments, Segment{Num: len(segments), Start: start, End: end, Text: record[2]})
+	}
+	return segments, nil
+}
+
+// parseTimestamp parses the srt (00:01:02,500) and WebVTT (00:01:02.500 or
+// 01:02.500) timestamps.
+func parseTimestamp(s string) (time.Duration, error) {
+	parts := strings.Split(strings.Replace(s, ",", ".", 1), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+
+	var total float64
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		// only the seconds have a fraction
+		if err != nil || n < 0 || (i < len(parts)-1 && strings.Contains(part, ".")) {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		total = total*60 + n
+	}
+	return seconds(total), nil
+}
+
+// seconds converts seconds to a duration rounded to the microsecond.
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Round(s*1e6)) * time.Microsecond
+}
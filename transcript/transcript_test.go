@@ -0,0 +1,101 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRead(t *testing.T) {
+	files := map[string]string{
+		"jfk.srt":  "1\n00:00:00,000 --> 00:00:01,500\nAnd so my fellow Americans,\n\n",
+		"jfk.vtt":  "WEBVTT\n\n00:00.000 --> 00:01.500\nAnd so my fellow Americans,\n\n",
+		"jfk.csv":  "start,end,text\n0s,1.5s,\" And so my fellow Americans,\"\n",
+		"jfk.json": `{"segments":[{"id":0,"start":0,"end":1.5,"text":" And so my fellow Americans,","tokens":[{"id":7,"text":" And","p":0.5,"start":0,"end":0.25}]}]}`,
+	}
+	want := Segment{Num: 0, Start: 0, End: 1500 * time.Millisecond, Text: " And so my fellow Americans,"}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Read(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("Read() = %+v, want 1 segment", got)
+			}
+			tokens := got[0].Tokens
+			got[0].Tokens = nil
+			if !reflect.DeepEqual(got[0], want) {
+				t.Errorf("Read() = %+v, want %+v", got[0], want)
+			}
+			if name == "jfk.json" && !reflect.DeepEqual(tokens, []Token{{ID: 7, Text: " And", P: 0.5, End: 250 * time.Millisecond}}) {
+				t.Errorf("Read() tokens = %+v", tokens)
+			}
+		})
+	}
+}
+
+func TestRead_Unsupported(t *testing.T) {
+	for _, name := range []string{"talk.txt", "talk.docx"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Read(path); err == nil {
+			t.Errorf("Read(%q) error = nil, want an error", name)
+		}
+	}
+}
+
+func TestParseCues(t *testing.T) {
+	vtt := "\ufeffWEBVTT\r\n\r\nNOTE generated\r\n\r\n" +
+		"intro\r\n00:01.000 --> 00:04.250 align:start\r\nHello\r\nworld\r\n\r\n" +
+		"01:00:00.000 --> 01:00:01.000\r\nBye\r\n"
+
+	got, err := parseCues(strings.NewReader(vtt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Segment{
+		{Num: 0, Start: time.Second, End: 4250 * time.Millisecond, Text: " Hello world"},
+		{Num: 1, Start: time.Hour, End: time.Hour + time.Second, Text: " Bye"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCues() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "01:02:03,004", want: time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond},
+		{in: "01:02:03.004", want: time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond},
+		{in: "02:03.500", want: 2*time.Minute + 3500*time.Millisecond},
+		{in: "3.5", wantErr: true},
+		{in: "00:xx:01.000", wantErr: true},
+		{in: "00:01.5:01.000", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseTimestamp(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimestamp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseTimestamp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
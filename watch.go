@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/sink"
+	"github.com/appleboy/go-whisper/watcher"
+	"github.com/appleboy/go-whisper/whisper"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+)
+
+func watchCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "watch",
+		Usage:     "watch folders and transcribe newly arrived audio files",
+		ArgsUsage: "[dir...]",
+		Description: "Every audio file is moved to the done/ or failed/ subfolder of its directory once transcribed.\n" +
+			"Without --output-folder, the outputs are written to the done/ subfolder, also for a file moved to\n" +
+			"failed/ if it failed after some of its outputs were saved.",
+		Action: watch,
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:    "dir",
+				Usage:   "directory to watch",
+				EnvVars: []string{"PLUGIN_WATCH_DIR", "INPUT_WATCH_DIR"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "ext",
+				Usage:   "audio file extensions to transcribe",
+				EnvVars: []string{"PLUGIN_WATCH_EXT", "INPUT_WATCH_EXT"},
+				Value:   cli.NewStringSlice(watcher.DefaultExtensions...),
+			},
+			&cli.DurationFlag{
+				Name:    "stable-time",
+				Usage:   "how long a file must stop growing before it is transcribed",
+				EnvVars: []string{"PLUGIN_WATCH_STABLE_TIME", "INPUT_WATCH_STABLE_TIME"},
+				Value:   5 * time.Second,
+			},
+			&cli.DurationFlag{
+				Name:    "poll-interval",
+				Usage:   "how often the directories are rescanned, needed for NFS shares",
+				EnvVars: []string{"PLUGIN_WATCH_POLL_INTERVAL", "INPUT_WATCH_POLL_INTERVAL"},
+				Value:   30 * time.Second,
+			},
+		},
+	}
+}
+
+func watch(c *cli.Context) error {
+	cfg := newSetting(c)
+	cfg.Watch = config.Watch{
+		Dirs:         append(c.StringSlice("dir"), c.Args().Slice()...),
+		Extensions:   c.StringSlice("ext"),
+		StableTime:   c.Duration("stable-time"),
+		PollInterval: c.Duration("poll-interval"),
+	}
+	setupDebug(&cfg)
+
+	if err := resolveModel(&cfg); err != nil {
+		return err
+	}
+
+	// validate the shared options once before loading the model
+	check := cfg.Whisper
+	check.AudioPath = "-"
+	if err := check.Validate(); err != nil {
+		return err
+	}
+	if err := whisper.CheckFormats(cfg.Whisper.OutputFormat); err != nil {
+		return err
+	}
+
+	model, err := whisper.LoadModel(cfg.Whisper.Model)
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+
+	out, err := sink.New(cfg.Whisper.OutputFolder, &cfg.S3, cfg.Source.Insecure)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(c.Context, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	w := watcher.New(&cfg.Watch, func(ctx context.Context, path string) error {
+		job := cfg
+		job.Whisper.AudioPath = path
+		// without an output folder, keep the outputs next to the processed input
+		if job.Whisper.OutputFolder == "" {
+			job.Whisper.OutputFolder = filepath.Join(filepath.Dir(path), watcher.DoneFolder)
+		}
+
+		ctx = log.Ctx(ctx).With().Str("input", path).Logger().WithContext(ctx)
+		_, err := transcribe(ctx, &job, whisper.WithSink(out), whisper.WithModel(model))
+		return err
+	})
+
+	return w.Run(ctx)
+}
@@ -0,0 +1,269 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DoneFolder is the subfolder for inputs transcribed successfully.
+	DoneFolder = "done"
+	// FailedFolder is the subfolder for inputs that failed to transcribe.
+	FailedFolder = "failed"
+)
+
+// DefaultExtensions are the audio file extensions watched by default.
+var DefaultExtensions = []string{
+	".wav", ".mp3", ".m4a", ".mp4", ".aac", ".flac", ".ogg", ".opus", ".webm", ".wma", ".amr",
+}
+
+// Handler transcribes a single audio file.
+type Handler func(ctx context.Context, path string) error
+
+// Watcher monitors directories and passes newly arrived audio files to the handler.
+// Files are processed one at a time, after they stop growing, and are moved
+// to the done or failed subfolder afterwards.
+type Watcher struct {
+	cfg     *config.Watch
+	handler Handler
+	queue   chan string
+
+	mu      sync.Mutex
+	pending map[string]struct{}
+}
+
+// New creates a new watcher.
+func New(cfg *config.Watch, handler Handler) *Watcher {
+	return &Watcher{
+		cfg:     cfg,
+		handler: handler,
+		queue:   make(chan string, 64),
+		pending: map[string]struct{}{},
+	}
+}
+
+// Run watches the directories until the context is canceled.
+func (w *Watcher) Run(ctx context.Context) error {
+	if len(w.cfg.Dirs) == 0 {
+		return errors.New("at least one watch directory is required")
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fsw.Close()
+
+	for _, dir := range w.cfg.Dirs {
+		for _, sub := range []string{DoneFolder, FailedFolder} {
+			if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+				return err
+			}
+		}
+		if err := fsw.Add(dir); err != nil {
+			return fmt.Errorf("watch %s: %w", dir, err)
+		}
+		log.Info().Str("dir", dir).Msg("watching directory")
+	}
+
+	go w.process(ctx)
+
+	// pick up files that arrived while the daemon was down
+	w.scan(ctx)
+
+	// inotify doesn't report writes from other NFS clients, so rescan periodically.
+	poll := w.cfg.PollInterval
+	if poll <= 0 {
+		poll = 30 * time.Second
+	}
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Create) || ev.Has(fsnotify.Write) {
+				w.track(ctx, ev.Name)
+			}
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			log.Error().Err(err).Msg("watch error")
+		case <-ticker.C:
+			w.scan(ctx)
+		}
+	}
+}
+
+// scan tracks every audio file already in the watched directories.
+func (w *Watcher) scan(ctx context.Context) {
+	for _, dir := range w.cfg.Dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			log.Error().Err(err).Str("dir", dir).Msg("scan directory error")
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			w.track(ctx, filepath.Join(dir, entry.Name()))
+		}
+	}
+}
+
+// track waits for the audio file to stop growing and queues it.
+func (w *Watcher) track(ctx context.Context, path string) {
+	if !w.isAudio(path) {
+		return
+	}
+
+	w.mu.Lock()
+	if _, ok := w.pending[path]; ok {
+		w.mu.Unlock()
+		return
+	}
+	w.pending[path] = struct{}{}
+	w.mu.Unlock()
+
+	go func() {
+		if err := WaitStable(ctx, path, w.stableTime()); err != nil {
+			if !errors.Is(err, context.Canceled) {
+				log.Warn().Err(err).Str("path", path).Msg("skip file")
+			}
+			w.done(path)
+			return
+		}
+
+		select {
+		case w.queue <- path:
+		case <-ctx.Done():
+		}
+	}()
+}
+
+// process transcribes the queued files one by one.
+func (w *Watcher) process(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case path := <-w.queue:
+			folder := DoneFolder
+			log.Info().Str("path", path).Msg("transcribe file")
+			if err := w.handler(ctx, path); err != nil {
+				log.Error().Err(err).Str("path", path).Msg("transcribe file error")
+				folder = FailedFolder
+			}
+
+			dst, err := Move(path, filepath.Join(filepath.Dir(path), folder))
+			if err != nil {
+				log.Error().Err(err).Str("path", path).Msg("move file error")
+			} else {
+				log.Info().Str("path", dst).Msg("move file")
+			}
+			w.done(path)
+		}
+	}
+}
+
+func (w *Watcher) done(path string) {
+	w.mu.Lock()
+	delete(w.pending, path)
+	w.mu.Unlock()
+}
+
+func (w *Watcher) stableTime() time.Duration {
+	if w.cfg.StableTime <= 0 {
+		return 5 * time.Second
+	}
+	return w.cfg.StableTime
+}
+
+func (w *Watcher) isAudio(path string) bool {
+	exts := w.cfg.Extensions
+	if len(exts) == 0 {
+		exts = DefaultExtensions
+	}
+
+	ext := filepath.Ext(path)
+	for _, v := range exts {
+		if !strings.HasPrefix(v, ".") {
+			v = "." + v
+		}
+		if strings.EqualFold(ext, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WaitStable blocks until the size and modification time of the file
+// stay the same for the given interval.
+func WaitStable(ctx context.Context, path string, interval time.Duration) error {
+	prev, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+
+		cur, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if cur.Size() == prev.Size() && cur.ModTime().Equal(prev.ModTime()) {
+			return nil
+		}
+		prev = cur
+	}
+}
+
+// Move moves the file into the folder and returns the new path.
+// A timestamp is added to the name if the folder already has a file with the same name.
+func Move(path, folder string) (string, error) {
+	if err := os.MkdirAll(folder, 0o755); err != nil {
+		return "", err
+	}
+
+	name := filepath.Base(path)
+	dst := filepath.Join(folder, name)
+	if _, err := os.Stat(dst); err == nil {
+		ext := filepath.Ext(name)
+		dst = filepath.Join(folder, fmt.Sprintf(
+			"%s-%s%s",
+			strings.TrimSuffix(name, ext),
+			time.Now().Format("20060102150405"),
+			ext,
+		))
+	}
+
+	if err := os.Rename(path, dst); err != nil {
+		return "", err
+	}
+
+	return dst, nil
+}
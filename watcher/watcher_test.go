@@ -0,0 +1,124 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+)
+
+func TestMove(t *testing.T) {
+	dir := t.TempDir()
+	folder := filepath.Join(dir, DoneFolder)
+
+	for i := 0; i < 2; i++ {
+		src := filepath.Join(dir, "call.wav")
+		if err := os.WriteFile(src, []byte("go-whisper"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := Move(src, folder)
+		if err != nil {
+			t.Fatalf("Move() error = %v", err)
+		}
+		if filepath.Dir(got) != folder {
+			t.Errorf("Move() = %v, want file in %v", got, folder)
+		}
+		if i == 0 && filepath.Base(got) != "call.wav" {
+			t.Errorf("Move() = %v, want call.wav", got)
+		}
+		if i == 1 && (filepath.Base(got) == "call.wav" || !strings.HasPrefix(filepath.Base(got), "call-")) {
+			t.Errorf("Move() = %v, want renamed file", got)
+		}
+		if _, err := os.Stat(src); !os.IsNotExist(err) {
+			t.Errorf("Move() source still exists")
+		}
+	}
+}
+
+func TestWaitStable(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "call.wav")
+	if err := os.WriteFile(name, []byte("go"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// keep growing the file for a while
+	go func() {
+		f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		for i := 0; i < 5; i++ {
+			time.Sleep(20 * time.Millisecond)
+			_, _ = f.WriteString("-whisper")
+		}
+	}()
+
+	if err := WaitStable(context.Background(), name, 60*time.Millisecond); err != nil {
+		t.Fatalf("WaitStable() error = %v", err)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 2+5*len("-whisper") {
+		t.Errorf("WaitStable() returned before the file stopped growing, size = %d", len(data))
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	dir := t.TempDir()
+	w := New(&config.Watch{
+		Dirs:         []string{dir},
+		StableTime:   20 * time.Millisecond,
+		PollInterval: 50 * time.Millisecond,
+	}, func(_ context.Context, path string) error {
+		if strings.Contains(path, "broken") {
+			return errors.New("transcribe failed")
+		}
+		// the path is passed as is, e.g. with spaces
+		_, err := os.Stat(path)
+		return err
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = w.Run(ctx)
+	}()
+
+	files := map[string]string{
+		"call.wav":             DoneFolder,
+		"call 2024-01-01.wav":  DoneFolder,
+		"it's $(id); done.m4a": DoneFolder,
+		"broken.mp3":           FailedFolder,
+		"notes.txt":            "",
+	}
+	time.Sleep(50 * time.Millisecond)
+	for name := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("go-whisper"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for name, folder := range files {
+		want := filepath.Join(dir, folder, name)
+		for {
+			if _, err := os.Stat(want); err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Watcher.Run() %s not found", want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
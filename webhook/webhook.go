@@ -9,6 +9,16 @@ import (
 	"net/http"
 	"net/url"
 	"time"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/metrics"
+	"github.com/appleboy/go-whisper/tracing"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client represents a webhook client that sends HTTP requests to a specified URL with custom headers.
@@ -37,7 +47,10 @@ func (c *Client) build(ctx context.Context, request any) (*http.Request, error)
 	)
 }
 
-func (c *Client) Send(ctx context.Context, payload any) error {
+func (c *Client) Send(ctx context.Context, payload any) (err error) {
+	ctx, span := tracing.Start(ctx, "webhook.send", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { tracing.End(span, err) }()
+
 	req, err := c.build(ctx, payload)
 	if err != nil {
 		return &RequestError{
@@ -53,16 +66,25 @@ func (c *Client) Send(ctx context.Context, payload any) error {
 	for k, v := range c.headers {
 		req.Header.Set(k, v)
 	}
+	// continue the trace in the receiving service
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	res, err := c.httpClient.Do(req)
 	if err != nil {
+		metrics.Webhook(0, true)
 		return &RequestError{
 			HTTPStatusCode: http.StatusInternalServerError,
-			Err:            fmt.Errorf("request failed with error: %s", err.Error()),
+			Err:            fmt.Errorf("request failed with error: %s", config.RedactError(err).Error()),
 		}
 	}
 	defer res.Body.Close()
 
+	span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
+	log.Ctx(ctx).Debug().
+		Str("phase", "webhook").
+		Int("status", res.StatusCode).
+		Msg("send webhook")
+	metrics.Webhook(res.StatusCode, isFailureStatusCode(res))
 	if isFailureStatusCode(res) {
 		return &RequestError{
 			HTTPStatusCode: res.StatusCode,
@@ -97,9 +119,8 @@ func NewClient(s string, insecure bool, headers map[string]string) *Client {
 		return nil
 	}
 
-	client := http.DefaultClient
-	client.Timeout = 5 * time.Second
-
+	// every client has its own, jobs run concurrently with other options
+	client := &http.Client{Timeout: 5 * time.Second}
 	if insecure {
 		client.Transport = &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
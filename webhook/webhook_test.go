@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/tracing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestClient_Send(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	if _, err := tracing.Setup(context.Background(), &config.Tracing{}, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ctx, span := tracing.Start(context.Background(), "job")
+	err := NewClient(srv.URL, false, map[string]string{"X-Token": "1234"}).Send(ctx, map[string]int{"progress": 100})
+	span.End()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header.Get("X-Token") != "1234" {
+		t.Errorf("X-Token = %q, want 1234", header.Get("X-Token"))
+	}
+	// the receiver continues the trace of the job
+	traceparent := header.Get("Traceparent")
+	if !strings.Contains(traceparent, span.SpanContext().TraceID().String()) {
+		t.Errorf("traceparent = %q, want trace %s", traceparent, span.SpanContext().TraceID())
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Name() != "webhook.send" || spans[0].Parent().SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("spans = %v, want webhook.send within job", spans)
+	}
+}
+
+func TestNewClient_Isolated(t *testing.T) {
+	insecure := NewClient("https://example.com/hook", true, nil)
+	secure := NewClient("https://example.com/hook", false, nil)
+
+	if insecure.httpClient == secure.httpClient || insecure.httpClient == http.DefaultClient {
+		t.Fatal("NewClient() shares its http client")
+	}
+	if secure.httpClient.Transport != nil {
+		t.Errorf("secure client transport = %v, want the default transport", secure.httpClient.Transport)
+	}
+	if http.DefaultClient.Timeout != 0 || http.DefaultClient.Transport != nil {
+		t.Errorf("NewClient() changed http.DefaultClient")
+	}
+}
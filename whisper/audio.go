@@ -1,34 +1,19 @@
 package whisper
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"os/exec"
 )
 
-// sh executes shell command.
-func sh(c string) (string, error) {
-	cmd := exec.Command("/bin/sh", "-c", c)
-	cmd.Env = os.Environ()
-	o, err := cmd.CombinedOutput()
-	return string(o), err
-}
-
-// AudioToWav converts audio to wav for transcribe.
-func audioToWav(src, dst string) error {
-	out, err := sh(fmt.Sprintf("ffmpeg -i %s -format s16le -ar 16000 -ac 1 -acodec pcm_s16le %s", src, dst))
+// audioToWav converts audio to 16 kHz mono wav for transcribe. ffmpeg runs
+// without a shell, so the paths are passed as is whatever their characters.
+func audioToWav(ctx context.Context, src, dst string) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-i", src, "-format", "s16le", "-ar", "16000", "-ac", "1", "-acodec", "pcm_s16le", dst)
+	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("error: %w out: %s", err, out)
 	}
 
 	return nil
 }
-
-// CutSilences cuts silences from audio.
-func cutSilences(src, dst string) error {
-	out, err := sh(fmt.Sprintf("vmh cut-silences %s %s", src, dst))
-	if err != nil {
-		return fmt.Errorf("error: %w out: %s", err, out)
-	}
-	return nil
-}
@@ -0,0 +1,38 @@
+package whisper
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestAudioToWav_Filename(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not found")
+	}
+	data, err := os.ReadFile("../testdata/jfk.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"call 2024-01-01.wav", "a;touch pwned;.wav", "$(touch pwned).wav"} {
+		t.Run(name, func(t *testing.T) {
+			src := filepath.Join(dir, name)
+			if err := os.WriteFile(src, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			dst := filepath.Join(dir, "converted.wav")
+			if err := audioToWav(context.Background(), src, dst); err != nil {
+				t.Fatalf("audioToWav() error = %v", err)
+			}
+			os.Remove(dst)
+			if _, err := os.Stat("pwned"); err == nil {
+				os.Remove("pwned")
+				t.Error("audioToWav() ran a command of the filename")
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package whisper
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"math"
+	"time"
+
+	"github.com/appleboy/go-whisper/cache"
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/metrics"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+// WithCache reuses the results decoded before for the same audio and settings.
+func WithCache(c *cache.Cache) Option {
+	return func(e *Engine) {
+		e.cache = c
+	}
+}
+
+// cacheEntry is a cached result, the segments before the post-processing.
+type cacheEntry struct {
+	Language string            `json:"language,omitempty"`
+	Segments []whisper.Segment `json:"segments"`
+}
+
+// cacheSettings are the options changing the decoded segments. The output
+// and post-processing options are left out, they apply to a cached result.
+type cacheSettings struct {
+	Model            string        `json:"model"`
+	Language         string        `json:"language"`
+	Translate        bool          `json:"translate"`
+	SpeedUp          bool          `json:"speed_up"`
+	Prompt           string        `json:"prompt"`
+	MaxContext       uint          `json:"max_context"`
+	BeamSize         uint          `json:"beam_size"`
+	EntropyThold     float64       `json:"entropy_thold"`
+	Temperature      float64       `json:"temperature"`
+	TemperatureInc   float64       `json:"temperature_inc"`
+	BestOf           uint          `json:"best_of"`
+	LogprobThold     float64       `json:"logprob_thold"`
+	NoSpeechThold    float64       `json:"no_speech_thold"`
+	Offset           time.Duration `json:"offset"`
+	Duration         time.Duration `json:"duration"`
+	SplitOnWord      bool          `json:"split_on_word"`
+	MaxSegmentLength uint          `json:"max_segment_length"`
+	MaxTokens        uint          `json:"max_tokens"`
+	TokenTimestamps  bool          `json:"token_timestamps"`
+	TokenThold       float64       `json:"token_thold"`
+	TokenSumThold    float64       `json:"token_sum_thold"`
+	AudioCtx         uint          `json:"audio_ctx"`
+	VAD              config.VAD    `json:"vad"`
+	Glossary         []string      `json:"glossary,omitempty"`
+	GlossaryChunk    time.Duration `json:"glossary_chunk,omitempty"`
+}
+
+// newCacheSettings returns the decoding options of the configuration,
+// model is the digest of the model file.
+func newCacheSettings(cfg *config.Whisper, model string) cacheSettings {
+	return cacheSettings{
+		Model:            model,
+		Language:         cfg.Language,
+		Translate:        cfg.Translate,
+		SpeedUp:          cfg.SpeedUp,
+		Prompt:           cfg.Prompt,
+		MaxContext:       cfg.MaxContext,
+		BeamSize:         cfg.BeamSize,
+		EntropyThold:     cfg.EntropyThold,
+		Temperature:      cfg.Temperature,
+		TemperatureInc:   cfg.TemperatureInc,
+		BestOf:           cfg.BestOf,
+		LogprobThold:     cfg.LogprobThold,
+		NoSpeechThold:    cfg.NoSpeechThold,
+		Offset:           cfg.Offset,
+		Duration:         cfg.Duration,
+		SplitOnWord:      cfg.SplitOnWord,
+		MaxSegmentLength: cfg.MaxSegmentLength,
+		MaxTokens:        cfg.MaxTokens,
+		TokenTimestamps:  cfg.TokenTimestamps,
+		TokenThold:       cfg.TokenThold,
+		TokenSumThold:    cfg.TokenSumThold,
+		AudioCtx:         cfg.AudioCtx,
+		VAD:              cfg.VAD,
+	}
+}
+
+// cacheKey hashes the PCM samples with the decoding settings.
+func cacheKey(data []float32, settings cacheSettings) string {
+	h := sha256.New()
+	writeSamples(h, data)
+	_ = json.NewEncoder(h).Encode(settings)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeSamples writes the samples as little-endian float32 in chunks,
+// so a long audio isn't copied at once.
+func writeSamples(h hash.Hash, data []float32) {
+	buf := make([]byte, 0, 4*4096)
+	for i, v := range data {
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(v))
+		if len(buf) == cap(buf) || i == len(data)-1 {
+			h.Write(buf)
+			buf = buf[:0]
+		}
+	}
+}
+
+// cacheKey returns the cache key of the audio, empty without a cache.
+func (e *Engine) cacheKey(data []float32) string {
+	if e.cache == nil {
+		return ""
+	}
+
+	model, err := e.cache.FileSum(e.cfg.Model)
+	if err != nil {
+		e.logger("cache").Warn().Err(err).Msg("hash model error, skip the cache")
+		return ""
+	}
+	settings := newCacheSettings(e.cfg, model)
+	// the terms of the glossary are in the prompt of every chunk
+	if e.glossary != nil {
+		settings.Glossary = e.glossary.terms
+		settings.GlossaryChunk = e.cfg.Glossary.Chunk
+	}
+	return cacheKey(data, settings)
+}
+
+// loadCache restores the segments of the key, it reports whether they were found.
+func (e *Engine) loadCache(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	logger := e.logger("cache")
+	data, ok := e.cache.Get(key)
+	if ok {
+		var entry cacheEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			logger.Warn().Err(err).Str("key", key).Msg("ignore unreadable cache entry")
+			ok = false
+		} else {
+			e.segments = entry.Segments
+			e.language = entry.Language
+		}
+	}
+	metrics.Cached(ok)
+	if ok {
+		logger.Info().Str("key", key).Int("segments", len(e.segments)).Msg("use cached result")
+	}
+
+	return ok
+}
+
+// storeCache saves the decoded segments under the key.
+func (e *Engine) storeCache(key string) {
+	if key == "" {
+		return
+	}
+
+	data, err := json.Marshal(cacheEntry{
+		Language: e.language,
+		Segments: e.segments,
+	})
+	if err == nil {
+		err = e.cache.Put(key, data)
+	}
+	if err != nil {
+		e.logger("cache").Warn().Err(err).Msg("save cache entry error")
+		return
+	}
+	e.logger("cache").Debug().Str("key", key).Msg("save cache entry")
+}
@@ -0,0 +1,150 @@
+package whisper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/appleboy/go-whisper/cache"
+	"github.com/appleboy/go-whisper/config"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+func TestCacheKey(t *testing.T) {
+	data := []float32{0, 0.25, -0.5, 1}
+	cfg := config.Whisper{Model: "model.bin", Language: "en", BeamSize: 5}
+	base := cacheKey(data, newCacheSettings(&cfg, "sum"))
+
+	tests := []struct {
+		name  string
+		data  []float32
+		cfg   func(c *config.Whisper)
+		model string
+		same  bool
+	}{
+		{name: "same input", same: true},
+		{name: "output format", cfg: func(c *config.Whisper) { c.OutputFormat = []string{"srt"} }, same: true},
+		{name: "post-processing", cfg: func(c *config.Whisper) { c.Clean.Enabled = true }, same: true},
+		{name: "threads", cfg: func(c *config.Whisper) { c.Threads = 8 }, same: true},
+		{name: "audio", data: []float32{0, 0.25, -0.5, 0.9}},
+		{name: "model", model: "other"},
+		{name: "language", cfg: func(c *config.Whisper) { c.Language = "de" }},
+		{name: "prompt", cfg: func(c *config.Whisper) { c.Prompt = "Kubernetes" }},
+		{name: "offset", cfg: func(c *config.Whisper) { c.Offset = time.Second }},
+		{name: "vad", cfg: func(c *config.Whisper) { c.VAD.Enabled = true }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cfg
+			if tt.cfg != nil {
+				tt.cfg(&c)
+			}
+			in := data
+			if tt.data != nil {
+				in = tt.data
+			}
+			model := "sum"
+			if tt.model != "" {
+				model = tt.model
+			}
+
+			got := cacheKey(in, newCacheSettings(&c, model))
+			if (got == base) != tt.same {
+				t.Errorf("cacheKey() = %s, base %s, want same %v", got, base, tt.same)
+			}
+		})
+	}
+}
+
+func TestEngine_Cache(t *testing.T) {
+	model := filepath.Join(t.TempDir(), "model.bin")
+	if err := os.WriteFile(model, []byte("model"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	results, err := cache.New(&config.Cache{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []float32{0, 0.25, -0.5, 1}
+	segments := []whisper.Segment{{Start: 0, End: time.Second, Text: " Hello"}}
+
+	newEngine := func() *Engine {
+		return &Engine{
+			cfg:    &config.Whisper{Model: model},
+			cache:  results,
+			parent: context.Background(),
+		}
+	}
+
+	e := newEngine()
+	key := e.cacheKey(data)
+	if key == "" {
+		t.Fatal("cacheKey() is empty")
+	}
+	if e.loadCache(key) {
+		t.Fatal("loadCache() hit an empty cache")
+	}
+	e.segments = segments
+	e.language = "en"
+	e.storeCache(key)
+
+	e = newEngine()
+	if !e.loadCache(e.cacheKey(data)) {
+		t.Fatal("loadCache() missed the stored result")
+	}
+	if !reflect.DeepEqual(e.segments, segments) || e.language != "en" {
+		t.Errorf("loadCache() = %v, %q, want %v, en", e.segments, e.language, segments)
+	}
+
+	if key := (&Engine{cfg: &config.Whisper{Model: model}}).cacheKey(data); key != "" {
+		t.Errorf("cacheKey() = %q without a cache, want empty", key)
+	}
+}
+
+func TestEngine_CacheHit(t *testing.T) {
+	model := filepath.Join(t.TempDir(), "model.bin")
+	if err := os.WriteFile(model, []byte("model"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	results, err := cache.New(&config.Cache{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]float32, 10*whisper.SampleRate)
+
+	newEngine := func() (*Engine, *fakeContext) {
+		e, ctx := newFakeEngine(t, &config.Whisper{Model: model, Language: "de"})
+		e.cache = results
+		return e, ctx
+	}
+
+	e, _ := newEngine()
+	if err := e.decodeAudio(data, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if e.Report().Cached {
+		t.Error("Report() is cached on a miss")
+	}
+
+	e, ctx := newEngine()
+	if err := e.decodeAudio(data, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	r := e.Report()
+	if r == nil || !r.Cached || r.Audio != 0 || r.Input != 10 || r.Conversion != 1000 {
+		t.Errorf("Report() = %+v, want cached with the input and conversion only", r)
+	}
+	if ctx.samples != 0 {
+		t.Errorf("decoded %d samples on a cache hit, want 0", ctx.samples)
+	}
+
+	e, _ = newEngine()
+	e.model.(*fakeModel).english = true
+	if err := e.decodeAudio(data, time.Second); err == nil {
+		t.Error("decodeAudio() returned the cached result for an English-only model")
+	}
+}
@@ -0,0 +1,186 @@
+package whisper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/appleboy/go-whisper/sink"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+// checkpointExt is the extension of the checkpoint saved while decoding.
+const checkpointExt = "checkpoint.json"
+
+// Checkpoint is the transcript decoded so far. It is saved periodically
+// so an interrupted run resumes from the committed offset.
+type Checkpoint struct {
+	Model    string            `json:"model"`
+	Samples  int               `json:"samples"`
+	Settings string            `json:"settings"` // Settings is the hash of the decoding settings.
+	Offset   time.Duration     `json:"offset"`
+	Language string            `json:"language,omitempty"`
+	Segments []whisper.Segment `json:"segments"`
+}
+
+// matches reports whether the checkpoint was saved for the same audio, model
+// and decoding settings. The audio is identified by its output name and its
+// number of samples.
+func (c *Checkpoint) matches(model string, samples int, settings string) bool {
+	return c.Model == model && c.Samples == samples && c.Settings == settings
+}
+
+// checkpointSettings hashes the decoding settings. The offset and duration
+// are left out, a resumed run may decode another window of the same audio.
+func (e *Engine) checkpointSettings() string {
+	settings := newCacheSettings(e.cfg, e.cfg.Model)
+	settings.Offset, settings.Duration = 0, 0
+	if e.glossary != nil {
+		settings.Glossary = e.glossary.terms
+		settings.GlossaryChunk = e.cfg.Glossary.Chunk
+	}
+
+	data, _ := json.Marshal(settings)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// readCheckpoint reads the checkpoint, it returns nil if there is none.
+func readCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var c Checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse checkpoint %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// writeCheckpoint replaces the checkpoint atomically, so the previous one
+// survives if the process dies while writing.
+func writeCheckpoint(path string, c *Checkpoint) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// checkpointPath returns the local path of the checkpoint, next to the outputs.
+// Remote and standard outputs keep it in the working directory.
+func (e *Engine) checkpointPath() string {
+	p := e.getOutputPath(checkpointExt)
+	if _, ok := e.sink.(*sink.Local); !ok {
+		return filepath.Base(p)
+	}
+	return p
+}
+
+// resume loads the checkpoint of an interrupted run of the same audio, model
+// and decoding settings. It returns the first sample left to decode in the [start, end) window.
+func (e *Engine) resume(start, end int) int {
+	logger := e.logger("checkpoint")
+	path := e.checkpointPath()
+
+	c, err := readCheckpoint(path)
+	if err != nil {
+		logger.Warn().Err(err).Msg("ignore unreadable checkpoint")
+		return start
+	}
+	if c == nil {
+		return start
+	}
+	if !c.matches(e.cfg.Model, e.samples, e.checkpointSettings()) {
+		logger.Warn().Str("checkpoint", path).Msg("ignore checkpoint of another audio, model or decoding settings")
+		return start
+	}
+
+	offset := min(max(samples(c.Offset), start), end)
+	e.segments = c.Segments
+	e.language = c.Language
+	e.resumed = true
+	logger.Info().
+		Str("checkpoint", path).
+		Dur("offset", duration(offset)).
+		Int("segments", len(c.Segments)).
+		Msg("resume from checkpoint")
+
+	return offset
+}
+
+// checkpoint saves the segments decoded so far once the interval elapsed,
+// or right away if forced. offset is the end of the committed audio.
+func (e *Engine) checkpoint(offset time.Duration, force bool) {
+	if e.cfg.Checkpoint.Interval <= 0 {
+		return
+	}
+	if !force && time.Since(e.checkpointed) < e.cfg.Checkpoint.Interval {
+		return
+	}
+	e.checkpointed = time.Now()
+
+	path := e.checkpointPath()
+	if err := writeCheckpoint(path, &Checkpoint{
+		Model:    e.cfg.Model,
+		Samples:  e.samples,
+		Settings: e.checkpointSettings(),
+		Offset:   offset,
+		Language: e.language,
+		Segments: e.segments,
+	}); err != nil {
+		e.logger("checkpoint").Warn().Err(err).Msg("save checkpoint error")
+		return
+	}
+	e.logger("checkpoint").Debug().
+		Str("checkpoint", path).
+		Dur("offset", offset).
+		Msg("save checkpoint")
+}
+
+// removeCheckpoint removes the checkpoint once the outputs are saved.
+func (e *Engine) removeCheckpoint() {
+	if e.cfg.Checkpoint.Interval <= 0 && !e.cfg.Checkpoint.Resume {
+		return
+	}
+	if err := os.Remove(e.checkpointPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		e.logger("checkpoint").Warn().Err(err).Msg("remove checkpoint error")
+	}
+}
+
+// resumePrompt returns the prompt followed by the end of the resumed text,
+// so the decoding continues in the same context.
+func resumePrompt(prompt string, segments []whisper.Segment) string {
+	text := []rune(segmentsText(segments))
+	if len(text) > maxPrompt {
+		text = text[len(text)-maxPrompt:]
+	}
+	return strings.TrimSpace(prompt + " " + string(text))
+}
@@ -0,0 +1,196 @@
+package whisper
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/sink"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+func TestCheckpoint_ReadWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audio.checkpoint.json")
+
+	got, err := readCheckpoint(path)
+	if err != nil || got != nil {
+		t.Fatalf("readCheckpoint() = %v, %v, want nil without a checkpoint", got, err)
+	}
+
+	want := &Checkpoint{
+		Model:    "models/ggml-small.bin",
+		Samples:  16000 * 60,
+		Offset:   42 * time.Second,
+		Language: "en",
+		Segments: []whisper.Segment{
+			{Num: 0, Start: 0, End: 20 * time.Second, Text: " Hello"},
+			{Num: 1, Start: 20 * time.Second, End: 42 * time.Second, Text: " world"},
+		},
+	}
+	if err := writeCheckpoint(path, want); err != nil {
+		t.Fatal(err)
+	}
+	// replacing the checkpoint keeps a single file
+	if err := writeCheckpoint(path, want); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*")); len(files) != 1 {
+		t.Errorf("files = %v, want only the checkpoint", files)
+	}
+
+	got, err = readCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readCheckpoint() = %+v, want %+v", got, want)
+	}
+}
+
+func TestEngine_Resume(t *testing.T) {
+	segments := []whisper.Segment{{Start: 0, End: 30 * time.Second, Text: " Hello"}}
+	saved := &Engine{cfg: &config.Whisper{Model: "model.bin", BeamSize: 5}}
+	checkpoint := &Checkpoint{
+		Model:    "model.bin",
+		Samples:  16000 * 60,
+		Settings: saved.checkpointSettings(),
+		Offset:   30 * time.Second,
+		Segments: segments,
+	}
+
+	tests := []struct {
+		name        string
+		model       string
+		samples     int
+		beamSize    uint
+		start, end  int
+		want        int
+		wantResumed bool
+	}{
+		{
+			name:        "resume from the committed offset",
+			model:       "model.bin",
+			samples:     16000 * 60,
+			beamSize:    5,
+			start:       0,
+			end:         16000 * 60,
+			want:        16000 * 30,
+			wantResumed: true,
+		},
+		{
+			name:        "offset after the checkpoint",
+			model:       "model.bin",
+			samples:     16000 * 60,
+			beamSize:    5,
+			start:       16000 * 40,
+			end:         16000 * 60,
+			want:        16000 * 40,
+			wantResumed: true,
+		},
+		{
+			name:        "window already decoded",
+			model:       "model.bin",
+			samples:     16000 * 60,
+			beamSize:    5,
+			start:       0,
+			end:         16000 * 10,
+			want:        16000 * 10,
+			wantResumed: true,
+		},
+		{
+			name:     "another model",
+			model:    "other.bin",
+			samples:  16000 * 60,
+			beamSize: 5,
+			start:    0,
+			end:      16000 * 60,
+			want:     0,
+		},
+		{
+			name:     "another audio",
+			model:    "model.bin",
+			samples:  16000 * 50,
+			beamSize: 5,
+			start:    0,
+			end:      16000 * 50,
+			want:     0,
+		},
+		{
+			name:     "other decoding settings",
+			model:    "model.bin",
+			samples:  16000 * 60,
+			beamSize: 8,
+			start:    0,
+			end:      16000 * 60,
+			want:     0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Engine{
+				cfg: &config.Whisper{
+					Model:        tt.model,
+					BeamSize:     tt.beamSize,
+					AudioPath:    "audio.wav",
+					OutputFolder: t.TempDir(),
+				},
+				sink:    sink.NewLocal(),
+				parent:  context.Background(),
+				samples: tt.samples,
+			}
+			if err := writeCheckpoint(e.checkpointPath(), checkpoint); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := e.resume(tt.start, tt.end); got != tt.want {
+				t.Errorf("resume() = %d, want %d", got, tt.want)
+			}
+			if e.resumed != tt.wantResumed {
+				t.Errorf("resumed = %v, want %v", e.resumed, tt.wantResumed)
+			}
+			if tt.wantResumed && !reflect.DeepEqual(e.segments, segments) {
+				t.Errorf("segments = %v, want %v", e.segments, segments)
+			}
+		})
+	}
+}
+
+func TestResumePrompt(t *testing.T) {
+	long := strings.Repeat("a", maxPrompt+10)
+
+	tests := []struct {
+		name     string
+		prompt   string
+		segments []whisper.Segment
+		want     string
+	}{
+		{
+			name:     "previous text",
+			segments: []whisper.Segment{{Text: " Hello"}, {Text: " world."}},
+			want:     "Hello world.",
+		},
+		{
+			name:     "after the prompt",
+			prompt:   "Glossary: Kubernetes.",
+			segments: []whisper.Segment{{Text: " Hello"}},
+			want:     "Glossary: Kubernetes. Hello",
+		},
+		{
+			name:     "end of a long text",
+			segments: []whisper.Segment{{Text: long}},
+			want:     long[10:],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resumePrompt(tt.prompt, tt.segments); got != tt.want {
+				t.Errorf("resumePrompt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
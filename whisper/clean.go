@@ -0,0 +1,255 @@
+package whisper
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/appleboy/go-whisper/config"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultPhrases are the boilerplate texts whisper often invents over silence or music.
+var DefaultPhrases = []string{
+	"Thank you for watching",
+	"Thanks for watching",
+	"Please subscribe to my channel",
+	"Like and subscribe",
+	"Subtitles by the Amara.org community",
+	"Transcription by CastingWords",
+	"Sous-titres réalisés par la communauté d'Amara.org",
+	"字幕由Amara.org社区提供",
+	"ご視聴ありがとうございました",
+}
+
+// cleaner removes hallucinations and repetition loops from the segments.
+type cleaner struct {
+	cfg     *config.Clean
+	phrases []*regexp.Regexp
+	log     *zerolog.Logger
+}
+
+// newCleaner creates the post-processor and loads the boilerplate phrases.
+func newCleaner(cfg *config.Clean) (*cleaner, error) {
+	phrases := DefaultPhrases
+	if cfg.PhrasesFile != "" {
+		var err error
+		phrases, err = readPhrases(cfg.PhrasesFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c := &cleaner{
+		cfg: cfg,
+		log: &log.Logger,
+	}
+	for _, phrase := range phrases {
+		c.phrases = append(c.phrases, regexp.MustCompile(`(?i)`+regexp.QuoteMeta(phrase)+`[\p{P}\s]*`))
+	}
+
+	return c, nil
+}
+
+// readPhrases reads one phrase per line, skipping empty lines and # comments.
+func readPhrases(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var phrases []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		phrases = append(phrases, line)
+	}
+
+	return phrases, scanner.Err()
+}
+
+// clean returns the segments without hallucinations. The audio is used to
+// drop segments without speech energy. Every removal is logged with the reason.
+func (c *cleaner) clean(segments []whisper.Segment, data []float32) []whisper.Segment {
+	var (
+		result  []whisper.Segment
+		last    string
+		repeats int
+	)
+
+	for _, segment := range segments {
+		if reason := c.drop(segment, data); reason != "" {
+			c.logRemoval(segment, reason)
+			continue
+		}
+
+		if text := c.removePhrases(segment.Text); text != segment.Text {
+			c.logRemoval(segment, "boilerplate hallucination")
+			if !hasLetter(text) {
+				continue
+			}
+			segment.Text = text
+		}
+
+		if text := collapseRepeats(segment.Text, c.maxRepeat()); text != segment.Text {
+			c.logRemoval(segment, "repeated n-gram")
+			segment.Text = text
+		}
+
+		// repetition across segments
+		key := normalize(segment.Text)
+		if key == last {
+			repeats++
+		} else {
+			last = key
+			repeats = 1
+		}
+		if repeats > c.maxRepeat() {
+			c.logRemoval(segment, "repeated segment")
+			continue
+		}
+
+		result = append(result, segment)
+	}
+
+	return result
+}
+
+// drop returns the reason to remove the whole segment, or an empty string.
+func (c *cleaner) drop(segment whisper.Segment, data []float32) string {
+	if c.cfg.MinProb > 0 {
+		if p, ok := averageProb(segment.Tokens); ok && p < c.cfg.MinProb {
+			return "low average token probability"
+		}
+	}
+
+	if data != nil {
+		start := min(samples(segment.Start), len(data))
+		end := min(samples(segment.End), len(data))
+		if start >= end || energy(data[start:end]) < c.cfg.MinEnergy {
+			return "no speech energy"
+		}
+	}
+
+	return ""
+}
+
+func (c *cleaner) maxRepeat() int {
+	if c.cfg.MaxRepeat < 1 {
+		return 1
+	}
+	return c.cfg.MaxRepeat
+}
+
+// removePhrases strips the boilerplate phrases from the text.
+func (c *cleaner) removePhrases(text string) string {
+	out := text
+	for _, re := range c.phrases {
+		out = re.ReplaceAllString(out, "")
+	}
+	if out == text {
+		return text
+	}
+
+	// keep the leading space whisper puts between segments
+	out = strings.TrimSpace(out)
+	if strings.HasPrefix(text, " ") {
+		out = " " + out
+	}
+	return out
+}
+
+func (c *cleaner) logRemoval(segment whisper.Segment, reason string) {
+	c.log.Info().
+		Dur("start", segment.Start).
+		Dur("end", segment.End).
+		Str("text", segment.Text).
+		Str("reason", reason).
+		Msg("remove hallucination")
+}
+
+// averageProb returns the average probability of the text tokens.
+func averageProb(tokens []whisper.Token) (float64, bool) {
+	var (
+		sum float64
+		n   int
+	)
+	for _, token := range tokens {
+		// special tokens such as [_BEG_] or [_TT_150]
+		if strings.HasPrefix(token.Text, "[_") {
+			continue
+		}
+		sum += float64(token.P)
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+
+	return sum / float64(n), true
+}
+
+// collapseRepeats keeps a single occurrence of any word n-gram repeated
+// consecutively more than maxRepeat times, e.g. "Thank you. Thank you. Thank you."
+func collapseRepeats(text string, maxRepeat int) string {
+	words := strings.Fields(text)
+	keys := make([]string, len(words))
+	for i, w := range words {
+		keys[i] = normalize(w)
+	}
+
+	changed := false
+	for n := 1; n <= len(words)/2; n++ {
+		for i := 0; i+n <= len(words); i++ {
+			count := 1
+			for j := i + n; j+n <= len(words) && equal(keys[i:i+n], keys[j:j+n]); j += n {
+				count++
+			}
+			if count <= maxRepeat {
+				continue
+			}
+			words = append(words[:i+n], words[i+count*n:]...)
+			keys = append(keys[:i+n], keys[i+count*n:]...)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return text
+	}
+
+	out := strings.Join(words, " ")
+	if strings.HasPrefix(text, " ") {
+		out = " " + out
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize lowercases the text and strips punctuation for comparison.
+func normalize(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}), " ")
+}
+
+func hasLetter(text string) bool {
+	return strings.IndexFunc(text, unicode.IsLetter) >= 0
+}
@@ -0,0 +1,142 @@
+package whisper
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+func TestCollapseRepeats(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "no repetition",
+			text: " And so my fellow Americans, ask not what your country can do for you.",
+			want: " And so my fellow Americans, ask not what your country can do for you.",
+		},
+		{
+			name: "allowed repetition",
+			text: "No, no, I said.",
+			want: "No, no, I said.",
+		},
+		{
+			name: "repeated phrase",
+			text: " Thank you. Thank you. Thank you. Thank you.",
+			want: " Thank you.",
+		},
+		{
+			name: "repeated word inside sentence",
+			text: "I went to the the the the store",
+			want: "I went to the store",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := collapseRepeats(tt.text, 2); got != tt.want {
+				t.Errorf("collapseRepeats() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleaner_clean(t *testing.T) {
+	segment := func(start, end time.Duration, text string, probs ...float32) whisper.Segment {
+		s := whisper.Segment{Start: start, End: end, Text: text}
+		for _, p := range probs {
+			s.Tokens = append(s.Tokens, whisper.Token{Text: "x", P: p})
+		}
+		return s
+	}
+
+	tests := []struct {
+		name     string
+		cfg      config.Clean
+		segments []whisper.Segment
+		data     []float32
+		want     []string
+	}{
+		{
+			name: "repeated segments",
+			cfg:  config.Clean{MaxRepeat: 1},
+			segments: []whisper.Segment{
+				segment(0, time.Second, " Hello there."),
+				segment(time.Second, 2*time.Second, " hello there"),
+				segment(2*time.Second, 3*time.Second, " General Kenobi."),
+			},
+			want: []string{" Hello there.", " General Kenobi."},
+		},
+		{
+			name: "low average token probability",
+			cfg:  config.Clean{MinProb: 0.5},
+			segments: []whisper.Segment{
+				segment(0, time.Second, " confident", 0.9, 0.8),
+				segment(time.Second, 2*time.Second, " guess", 0.2, 0.3),
+			},
+			want: []string{" confident"},
+		},
+		{
+			name: "boilerplate hallucination",
+			cfg:  config.Clean{},
+			segments: []whisper.Segment{
+				segment(0, time.Second, " Thank you for watching!"),
+				segment(time.Second, 2*time.Second, " See you tomorrow. Thanks for watching."),
+			},
+			want: []string{" See you tomorrow."},
+		},
+		{
+			name: "no speech energy",
+			cfg:  config.Clean{MinEnergy: -60},
+			segments: []whisper.Segment{
+				segment(0, time.Second, " music"),
+				segment(time.Second, 2*time.Second, " speech"),
+			},
+			data: signal(time.Second, time.Second),
+			want: []string{" speech"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newCleaner(&tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, s := range c.clean(tt.segments, tt.data) {
+				got = append(got, s.Text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cleaner.clean() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCleaner_phrasesFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "phrases.txt")
+	if err := os.WriteFile(name, []byte("# custom list\n\nPlease like the video\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := newCleaner(&config.Clean{PhrasesFile: name})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := c.clean([]whisper.Segment{
+		{Text: " Please like the video."},
+		{Text: " Thank you for watching."},
+	}, nil)
+	if len(got) != 1 || got[0].Text != " Thank you for watching." {
+		t.Errorf("cleaner.clean() = %v, want only the default phrase kept", got)
+	}
+}
@@ -0,0 +1,151 @@
+package whisper
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+
+	whispercpp "github.com/ggerganov/whisper.cpp/bindings/go"
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+// maxDetectWindow is the audio length whisper looks at to identify the language.
+const maxDetectWindow = 30 * time.Second
+
+// LanguageProb is a detected language with its probability.
+type LanguageProb struct {
+	Language    string  `json:"language"`
+	Probability float64 `json:"probability"`
+}
+
+// langDetector is implemented by the whisper context after Process has run.
+type langDetector interface {
+	WhisperLangAutoDetect(offsetMs int, threads int) ([]float32, error)
+}
+
+// Language returns the configured language, or the detected one if it was auto.
+func (e *Engine) Language() string {
+	return e.language
+}
+
+// detectLanguage returns the configured language, or identifies it from the
+// first window of the processed audio if the language is auto.
+func (e *Engine) detectLanguage() (string, error) {
+	if e.cfg.Language != "" && e.cfg.Language != "auto" {
+		return e.cfg.Language, nil
+	}
+
+	if !e.model.IsMultilingual() {
+		return "en", nil
+	}
+
+	detector, ok := e.ctx.(langDetector)
+	if !ok {
+		return "", errors.New("language detection is not supported by the whisper binding")
+	}
+
+	probs, err := detector.WhisperLangAutoDetect(0, int(e.cfg.Threads))
+	if err != nil {
+		return "", err
+	}
+
+	return topLanguages(probs, 1)[0].Language, nil
+}
+
+// DetectLanguage runs only whisper's language identification, without transcribing.
+// The audio is sampled with the given number of windows spread evenly over the
+// recording, each covering up to 30 seconds, and the probabilities are averaged.
+// It returns the top-k languages.
+func DetectLanguage(cfg *config.Whisper, windows int, length time.Duration, topK int) ([]LanguageProb, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	data, err := loadAudio(context.Background(), cfg.AudioPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("audio is empty")
+	}
+
+	ctx := whispercpp.Whisper_init(cfg.Model)
+	if ctx == nil {
+		return nil, whisper.ErrUnableToLoadModel
+	}
+	defer ctx.Whisper_free()
+
+	if ctx.Whisper_is_multilingual() == 0 {
+		return nil, whisper.ErrModelNotMultilingual
+	}
+
+	var sum []float32
+	ws := detectWindows(len(data), windows, length)
+	for _, w := range ws {
+		if err := ctx.Whisper_pcm_to_mel(data[w.start:w.end], int(cfg.Threads)); err != nil {
+			return nil, err
+		}
+		probs, err := ctx.Whisper_lang_auto_detect(0, int(cfg.Threads))
+		if err != nil {
+			return nil, err
+		}
+		if sum == nil {
+			sum = make([]float32, len(probs))
+		}
+		for i, p := range probs {
+			sum[i] += p / float32(len(ws))
+		}
+	}
+
+	return topLanguages(sum, topK), nil
+}
+
+// detectWindows spreads the windows evenly over n samples.
+func detectWindows(n, windows int, length time.Duration) []region {
+	if windows < 1 {
+		windows = 1
+	}
+	if length <= 0 || length > maxDetectWindow {
+		length = maxDetectWindow
+	}
+	size := min(samples(length), n)
+
+	result := make([]region, windows)
+	for i := range result {
+		start := 0
+		if windows > 1 {
+			start = i * (n - size) / (windows - 1)
+		}
+		result[i] = region{start: start, end: start + size}
+	}
+
+	return result
+}
+
+// topLanguages returns the k most probable languages.
+func topLanguages(probs []float32, k int) []LanguageProb {
+	langs := make([]LanguageProb, 0, len(probs))
+	for i, p := range probs {
+		lang := whispercpp.Whisper_lang_str(i)
+		if lang == "" {
+			continue
+		}
+		langs = append(langs, LanguageProb{
+			Language:    lang,
+			Probability: float64(p),
+		})
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].Probability > langs[j].Probability
+	})
+
+	if k > 0 && k < len(langs) {
+		langs = langs[:k]
+	}
+
+	return langs
+}
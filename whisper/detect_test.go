@@ -0,0 +1,64 @@
+package whisper
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDetectWindows(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		windows int
+		length  time.Duration
+		want    []region
+	}{
+		{
+			name:    "short audio",
+			n:       samples(10 * time.Second),
+			windows: 1,
+			length:  30 * time.Second,
+			want:    []region{{start: 0, end: samples(10 * time.Second)}},
+		},
+		{
+			name:    "window capped at 30 seconds",
+			n:       samples(2 * time.Minute),
+			windows: 1,
+			length:  time.Minute,
+			want:    []region{{start: 0, end: samples(30 * time.Second)}},
+		},
+		{
+			name:    "windows spread over the audio",
+			n:       samples(70 * time.Second),
+			windows: 3,
+			length:  10 * time.Second,
+			want: []region{
+				{start: 0, end: samples(10 * time.Second)},
+				{start: samples(30 * time.Second), end: samples(40 * time.Second)},
+				{start: samples(60 * time.Second), end: samples(70 * time.Second)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectWindows(tt.n, tt.windows, tt.length); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detectWindows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopLanguages(t *testing.T) {
+	// language ids: 0 en, 1 zh, 2 de, 3 es
+	probs := []float32{0.2, 0.7, 0.05, 0.05}
+
+	got := topLanguages(probs, 2)
+	want := []LanguageProb{
+		{Language: "zh", Probability: float64(float32(0.7))},
+		{Language: "en", Probability: float64(float32(0.2))},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("topLanguages() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,339 @@
+package whisper
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/appleboy/go-whisper/config"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// maxPromptTokens is the longest prompt whisper keeps, half of the text context of the models.
+const maxPromptTokens = 224
+
+// charsPerToken estimates the token count of a text, there is no tokenizer
+// in the bindings. Names and jargon split into short tokens, so it's low.
+const charsPerToken = 3
+
+// minCorrection is the shortest word corrected to a term, shorter words
+// are too close to common words.
+const minCorrection = 4
+
+// Correction is a near-miss spelling replaced by a glossary term.
+type Correction struct {
+	Start time.Duration // Start and End are the time of the corrected segment.
+	End   time.Duration
+	From  string
+	To    string
+}
+
+// glossary holds the domain terms put in the prompt of every chunk and
+// corrected in the decoded text.
+type glossary struct {
+	terms     []string
+	threshold float64
+	log       *zerolog.Logger
+}
+
+// newGlossary loads the terms of the glossary file, nil without a file.
+func newGlossary(cfg *config.Glossary) (*glossary, error) {
+	if cfg.File == "" {
+		return nil, nil
+	}
+
+	terms, err := readPhrases(cfg.File)
+	if err != nil {
+		return nil, err
+	}
+
+	return &glossary{
+		terms:     terms,
+		threshold: cfg.Threshold,
+		log:       &log.Logger,
+	}, nil
+}
+
+// promptBudget returns the number of prompt tokens whisper keeps, capped by the max context.
+func promptBudget(cfg *config.Whisper) int {
+	return min(int(cfg.MaxContext), maxPromptTokens)
+}
+
+// estimateTokens returns the estimated number of tokens of the text.
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// prompt returns the configured prompt followed by the terms and the end of
+// the previous text, within the token budget. The terms are added in the
+// order of the file, a third of the budget is kept for the previous text.
+func (g *glossary) prompt(prompt, previous string, budget int) string {
+	budget -= estimateTokens(prompt)
+	reserve := 0
+	if previous != "" {
+		reserve = budget / 3
+	}
+
+	var (
+		terms []string
+		used  int
+	)
+	for _, term := range g.terms {
+		// the separator and the final period
+		n := estimateTokens(term) + 1
+		if used+n > budget-reserve {
+			continue
+		}
+		terms = append(terms, term)
+		used += n
+	}
+
+	parts := []string{prompt}
+	if len(terms) > 0 {
+		parts = append(parts, strings.Join(terms, ", ")+".")
+	}
+	if rest := budget - used; rest > 0 && previous != "" {
+		parts = append(parts, tail(previous, rest*charsPerToken))
+	}
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+// tail returns the last n characters of the text, without a partial first word.
+func tail(text string, n int) string {
+	r := []rune(strings.TrimSpace(text))
+	if len(r) <= n {
+		return string(r)
+	}
+	s := string(r[len(r)-n:])
+	if !unicode.IsSpace(r[len(r)-n-1]) {
+		if i := strings.IndexFunc(s, unicode.IsSpace); i >= 0 {
+			s = s[i:]
+		}
+	}
+	return strings.TrimSpace(s)
+}
+
+// segmentsText returns the text of the segments.
+func segmentsText(segments []whisper.Segment) string {
+	texts := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		texts = append(texts, strings.TrimSpace(segment.Text))
+	}
+	return strings.Join(texts, " ")
+}
+
+// correct replaces the near-miss spellings of the terms in the segments
+// and returns the substitutions.
+func (g *glossary) correct(segments []whisper.Segment) ([]whisper.Segment, []Correction) {
+	if g.threshold <= 0 {
+		return segments, nil
+	}
+
+	var corrections []Correction
+	result := make([]whisper.Segment, len(segments))
+	for i, segment := range segments {
+		text, fixes := g.correctText(segment.Text)
+		for _, fix := range fixes {
+			corrections = append(corrections, Correction{
+				Start: segment.Start,
+				End:   segment.End,
+				From:  fix[0],
+				To:    fix[1],
+			})
+			g.log.Debug().
+				Str("from", fix[0]).
+				Str("to", fix[1]).
+				Dur("start", segment.Start).
+				Msg("glossary correction")
+		}
+		segment.Text = text
+		result[i] = segment
+	}
+
+	if len(corrections) > 0 {
+		g.log.Info().Int("corrections", len(corrections)).Msg("corrected spellings to the glossary")
+	}
+	return result, corrections
+}
+
+// correctText replaces the words close to a term, the punctuation around
+// them is kept. A term may have been split into several words, e.g.
+// Kuber netes, so the words are also compared without the spaces.
+func (g *glossary) correctText(text string) (string, [][2]string) {
+	words := strings.Fields(text)
+
+	var (
+		out   []string
+		fixes [][2]string
+	)
+	for i := 0; i < len(words); {
+		term, n, from, score := g.match(words[i:])
+		// the words after the first one are as close to a term, e.g. on kubernetes
+		if n > 1 {
+			if _, next, _, s := g.match(words[i+1:]); next >= n-1 && s >= score {
+				n = 0
+			}
+		}
+		if n == 0 {
+			out = append(out, words[i])
+			i++
+			continue
+		}
+
+		if from == term {
+			out = append(out, words[i:i+n]...)
+		} else {
+			prefix, _, _ := splitPunct(words[i])
+			_, _, suffix := splitPunct(words[i+n-1])
+			out = append(out, prefix+term+suffix)
+			fixes = append(fixes, [2]string{from, term})
+		}
+		i += n
+	}
+
+	if len(fixes) == 0 {
+		return text, nil
+	}
+	lead := text[:len(text)-len(strings.TrimLeftFunc(text, unicode.IsSpace))]
+	return lead + strings.Join(out, " "), fixes
+}
+
+// match returns the term closest to the first words, the number of words
+// it replaces, their text without the surrounding punctuation and their
+// similarity. n is 0 if no term is close enough.
+func (g *glossary) match(words []string) (term string, n int, from string, best float64) {
+	for _, t := range g.terms {
+		k := len(strings.Fields(t))
+		for _, span := range []int{k, k + 1} {
+			if span > len(words) {
+				continue
+			}
+			candidate := core(words[:span])
+			if utf8.RuneCountInString(candidate) < minCorrection && candidate != t {
+				continue
+			}
+
+			lower := strings.ToLower(t)
+			score := max(
+				similarity(strings.ToLower(candidate), lower),
+				similarity(strings.ToLower(strings.ReplaceAll(candidate, " ", "")), lower),
+			)
+			if candidate == t {
+				score = 1.1
+			}
+			if score >= g.threshold && (score > best || score == best && span > n) {
+				best, term, n, from = score, t, span, candidate
+			}
+		}
+	}
+	return term, n, from, best
+}
+
+// core joins the words without the punctuation before the first one and after the last one.
+func core(words []string) string {
+	_, first, _ := splitPunct(words[0])
+	if len(words) == 1 {
+		return first
+	}
+	_, last, _ := splitPunct(words[len(words)-1])
+	return strings.Join(append(append([]string{first}, words[1:len(words)-1]...), last), " ")
+}
+
+// splitPunct splits the leading and trailing punctuation of a word.
+func splitPunct(word string) (prefix, core, suffix string) {
+	start := strings.IndexFunc(word, isTermRune)
+	if start < 0 {
+		return word, "", ""
+	}
+	end := strings.LastIndexFunc(word, isTermRune)
+	_, size := utf8.DecodeRuneInString(word[end:])
+	return word[:start], word[start : end+size], word[end+size:]
+}
+
+func isTermRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// similarity is 1 minus the edit distance of the texts divided by the longest one.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein returns the edit distance of the texts.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			sub := prev[j-1]
+			if a[i-1] != b[j-1] {
+				sub++
+			}
+			cur[j] = min(sub, prev[j]+1, cur[j-1]+1)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// decodeChunks decodes the [from, to) window of the audio in chunks, each
+// one prompted with the glossary and the end of the text before it. The
+// last segment of a chunk may be cut by the end of the chunk, so it's
+// decoded again at the start of the next chunk.
+func (e *Engine) decodeChunks(data []float32, from, to int) error {
+	size := to - from
+	if e.cfg.Glossary.Chunk > 0 {
+		size = samples(e.cfg.Glossary.Chunk)
+	}
+	segment, progress := e.cbSegment(), e.cbProgress()
+	budget := promptBudget(e.cfg)
+
+	for pos := from; pos < to; {
+		stop := min(pos+size, to)
+		shift := duration(pos)
+		// the chunk is decoded from the start, the offset is in the shift
+
+		e.ctx.SetOffset(0)
+		e.ctx.SetDuration(0)
+		e.ctx.SetPrompt(e.glossary.prompt(e.cfg.Prompt, segmentsText(e.segments), budget))
+
+		var pending []whisper.Segment
+		err := e.ctx.Process(data[pos:stop], func(s whisper.Segment) {
+			pending = append(pending, s)
+		}, func(p int) {
+			done := pos - from + (stop-pos)*min(p, 100)/100
+			progress(int(int64(done) * 100 / int64(to-from)))
+		})
+		if err != nil {
+			return err
+		}
+
+		pending = Shift(pending, shift)
+		next := stop
+		if stop < to && len(pending) > 1 {
+			last := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			next = max(samples(last.Start), pos+1)
+		}
+		for _, s := range pending {
+			s.Num = len(e.segments)
+			segment(s)
+		}
+		pos = next
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package whisper
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/transcript"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+	"github.com/rs/zerolog"
+)
+
+func TestNewGlossary(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "glossary.txt")
+	if err := os.WriteFile(name, []byte("# products\nKubernetes\n\n  Grafana Loki  \n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := newGlossary(&config.Glossary{File: name, Threshold: 0.8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Kubernetes", "Grafana Loki"}; !reflect.DeepEqual(g.terms, want) {
+		t.Errorf("terms = %q, want %q", g.terms, want)
+	}
+
+	if g, err := newGlossary(&config.Glossary{}); g != nil || err != nil {
+		t.Errorf("newGlossary() = %v, %v, want nil without a file", g, err)
+	}
+}
+
+func TestGlossaryPrompt(t *testing.T) {
+	g := &glossary{terms: []string{"Kubernetes", "kubectl", "Grafana Loki"}}
+
+	tests := []struct {
+		name     string
+		prompt   string
+		previous string
+		budget   int
+		want     string
+	}{
+		{
+			name:   "all terms",
+			budget: 32,
+			want:   "Kubernetes, kubectl, Grafana Loki.",
+		},
+		{
+			name:     "with the previous text",
+			prompt:   "Standup.",
+			previous: "we deployed the new cluster yesterday",
+			budget:   32,
+			want:     "Standup. Kubernetes, kubectl, Grafana Loki. we deployed the new cluster yesterday",
+		},
+		{
+			name:     "terms over the budget",
+			previous: "we deployed the new cluster yesterday",
+			budget:   12,
+			want:     "Kubernetes. new cluster yesterday",
+		},
+		{
+			name:   "no budget",
+			prompt: "Standup.",
+			want:   "Standup.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.prompt(tt.prompt, tt.previous, tt.budget); got != tt.want {
+				t.Errorf("prompt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCorrectText(t *testing.T) {
+	g := &glossary{terms: []string{"Kubernetes", "TensorFlow", "Grafana Loki", "Go"}, threshold: 0.8}
+
+	tests := []struct {
+		name  string
+		text  string
+		want  string
+		fixes [][2]string
+	}{
+		{
+			name:  "misspelling",
+			text:  " We run Kubernetis, mostly.",
+			want:  " We run Kubernetes, mostly.",
+			fixes: [][2]string{{"Kubernetis", "Kubernetes"}},
+		},
+		{
+			name:  "case",
+			text:  " on kubernetes",
+			want:  " on Kubernetes",
+			fixes: [][2]string{{"kubernetes", "Kubernetes"}},
+		},
+		{
+			name:  "split term",
+			text:  " (Tensor Flow) models",
+			want:  " (TensorFlow) models",
+			fixes: [][2]string{{"Tensor Flow", "TensorFlow"}},
+		},
+		{
+			name:  "several words",
+			text:  " the grafana loky logs",
+			want:  " the Grafana Loki logs",
+			fixes: [][2]string{{"grafana loky", "Grafana Loki"}},
+		},
+		{
+			name: "exact",
+			text: " Kubernetes and Grafana Loki.",
+			want: " Kubernetes and Grafana Loki.",
+		},
+		{
+			name: "short words",
+			text: " go to the governance meeting",
+			want: " go to the governance meeting",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, fixes := g.correctText(tt.text)
+			if got != tt.want {
+				t.Errorf("correctText() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(fixes, tt.fixes) {
+				t.Errorf("fixes = %q, want %q", fixes, tt.fixes)
+			}
+		})
+	}
+}
+
+func TestGlossaryCorrect(t *testing.T) {
+	nop := zerolog.Nop()
+	g := &glossary{terms: []string{"Kubernetes"}, threshold: 0.8, log: &nop}
+	segments := []whisper.Segment{
+		{Start: 0, End: time.Second, Text: " Hello."},
+		{Start: time.Second, End: 3 * time.Second, Text: " Kubernetis is up."},
+	}
+
+	got, corrections := g.correct(segments)
+	if got[1].Text != " Kubernetes is up." || segments[1].Text != " Kubernetis is up." {
+		t.Errorf("correct() = %q, want a corrected copy", got[1].Text)
+	}
+	want := []Correction{{Start: time.Second, End: 3 * time.Second, From: "Kubernetis", To: "Kubernetes"}}
+	if !reflect.DeepEqual(corrections, want) {
+		t.Errorf("corrections = %+v, want %+v", corrections, want)
+	}
+
+	var doc transcript.JSON
+	if err := json.NewDecoder(strings.NewReader(renderJSONCorrections(got, corrections))).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if c := doc.Corrections; len(c) != 1 || c[0].Start != 1 || c[0].From != "Kubernetis" {
+		t.Errorf("json corrections = %+v, want the substitution", c)
+	}
+}
@@ -14,3 +14,13 @@ func srtTimestamp(t time.Duration) string {
 		(t%time.Second)/time.Millisecond,
 	)
 }
+
+// vttTimestamp converts time.Duration to WebVTT timestamp.
+func vttTimestamp(t time.Duration) string {
+	return fmt.Sprintf("%02d:%02d:%02d.%03d",
+		t/time.Hour,
+		(t%time.Hour)/time.Minute,
+		(t%time.Minute)/time.Second,
+		(t%time.Second)/time.Millisecond,
+	)
+}
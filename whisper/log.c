@@ -0,0 +1,8 @@
+#include <whisper.h>
+
+#include "_cgo_export.h"
+
+// whisperLog forwards the whisper.cpp logs to Go.
+void whisperLog(enum ggml_log_level level, const char * text, void * user_data) {
+	goWhisperLog((int)level, (char *)text);
+}
@@ -0,0 +1,107 @@
+package whisper
+
+/*
+#cgo LDFLAGS: -lwhisper -lm -lstdc++
+#include <whisper.h>
+
+extern void whisperLog(enum ggml_log_level level, const char * text, void * user_data);
+*/
+import "C"
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// capture collects the whisper.cpp logs while the timings are printed.
+var capture struct {
+	// run serializes the captures, the log callback is global
+	run    sync.Mutex
+	mu     sync.Mutex
+	active bool
+	buf    strings.Builder
+}
+
+// redirect sends the whisper.cpp logs through zerolog instead of stderr.
+var redirect struct {
+	mu      sync.Mutex
+	enabled bool
+	line    strings.Builder
+}
+
+// RedirectLogs sends the whisper.cpp logs through zerolog line by line,
+// e.g. so they are JSON like the other logs, instead of writing them to stderr.
+func RedirectLogs(enabled bool) {
+	redirect.mu.Lock()
+	defer redirect.mu.Unlock()
+	redirect.enabled = enabled
+}
+
+func init() {
+	C.whisper_log_set(C.ggml_log_callback(C.whisperLog), nil)
+}
+
+//export goWhisperLog
+func goWhisperLog(level C.int, text *C.char) {
+	s := C.GoString(text)
+
+	capture.mu.Lock()
+	if capture.active {
+		capture.buf.WriteString(s)
+	}
+	capture.mu.Unlock()
+
+	redirect.mu.Lock()
+	defer redirect.mu.Unlock()
+	if !redirect.enabled {
+		// keep printing the logs like the default callback of whisper.cpp
+		_, _ = os.Stderr.WriteString(s)
+		return
+	}
+
+	// a message may be split across several calls, log complete lines only
+	redirect.line.WriteString(s)
+	lines := strings.Split(redirect.line.String(), "\n")
+	redirect.line.Reset()
+	redirect.line.WriteString(lines[len(lines)-1])
+	for _, line := range lines[:len(lines)-1] {
+		if line = strings.TrimSpace(line); line != "" {
+			log.WithLevel(logLevel(int(level))).Str("phase", "whisper.cpp").Msg(line)
+		}
+	}
+}
+
+// logLevel maps the ggml log level to zerolog.
+func logLevel(level int) zerolog.Level {
+	switch level {
+	case 2:
+		return zerolog.ErrorLevel
+	case 3:
+		return zerolog.WarnLevel
+	case 5:
+		return zerolog.DebugLevel
+	}
+	return zerolog.InfoLevel
+}
+
+// captureLogs returns the whisper.cpp logs written while fn runs.
+func captureLogs(fn func()) string {
+	capture.run.Lock()
+	defer capture.run.Unlock()
+
+	capture.mu.Lock()
+	capture.active = true
+	capture.buf.Reset()
+	capture.mu.Unlock()
+
+	fn()
+
+	capture.mu.Lock()
+	defer capture.mu.Unlock()
+	capture.active = false
+	return capture.buf.String()
+}
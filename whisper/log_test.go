@@ -0,0 +1,65 @@
+package whisper
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// brokenModel returns a model file whisper.cpp logs about before it fails to load.
+func brokenModel(t *testing.T) string {
+	t.Helper()
+	model := filepath.Join(t.TempDir(), "broken.bin")
+	if err := os.WriteFile(model, []byte("not a model"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return model
+}
+
+func TestCaptureLogs(t *testing.T) {
+	model := brokenModel(t)
+	logs := captureLogs(func() {
+		_, _ = LoadModel(model)
+	})
+	if !strings.Contains(logs, "broken.bin") {
+		t.Errorf("captureLogs() = %q, want the whisper.cpp logs", logs)
+	}
+}
+
+func TestRedirectLogs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&buf)
+	RedirectLogs(true)
+	defer func() {
+		RedirectLogs(false)
+		log.Logger = logger
+	}()
+
+	_, _ = LoadModel(brokenModel(t))
+
+	levels := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry struct {
+			Level   string `json:"level"`
+			Phase   string `json:"phase"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid json log %q: %v", line, err)
+		}
+		if entry.Phase != "whisper.cpp" || strings.Contains(entry.Message, "\n") {
+			t.Errorf("entry = %+v", entry)
+		}
+		levels[entry.Level] = true
+	}
+	if !levels["info"] || !levels["error"] {
+		t.Errorf("levels = %v, want info and error", levels)
+	}
+}
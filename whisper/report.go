@@ -0,0 +1,115 @@
+package whisper
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/appleboy/go-whisper/sink"
+)
+
+// reportExt is the extension of the run report saved next to the outputs.
+const reportExt = "report.json"
+
+// Timings are the whisper.cpp timings of a run in milliseconds.
+type Timings struct {
+	Load   float64 `json:"load_ms"`
+	Mel    float64 `json:"mel_ms"`
+	Sample float64 `json:"sample_ms"`
+	Encode float64 `json:"encode_ms"`
+	Decode float64 `json:"decode_ms"`
+	Batchd float64 `json:"batchd_ms"`
+	Prompt float64 `json:"prompt_ms"`
+	Total  float64 `json:"total_ms"`
+}
+
+// Report describes the performance of a run, so it can be compared across versions.
+type Report struct {
+	Version        string   `json:"version,omitempty"`
+	Model          string   `json:"model"`
+	Threads        uint     `json:"threads"`
+	CPUFeatures    []string `json:"cpu_features"`
+	SystemInfo     string   `json:"system_info"`
+	Audio          float64  `json:"audio_seconds"` // Audio is the decoded audio, without the skipped window or silence.
+	Input          float64  `json:"input_seconds"` // Input is the length of the whole input.
+	Conversion     float64  `json:"conversion_ms"` // Conversion is the time to convert and read the audio.
+	Timings        Timings  `json:"timings"`
+	RealtimeFactor float64  `json:"realtime_factor"`  // RealtimeFactor is the total time divided by the audio duration.
+	Cached         bool     `json:"cached,omitempty"` // Cached is set if the segments were read from the result cache, nothing was decoded.
+}
+
+// timingPattern matches a line of whisper_print_timings, e.g.
+// "whisper_print_timings:   encode time =  1234.56 ms /     2 runs (  617.28 ms per run)".
+var timingPattern = regexp.MustCompile(`whisper_print_timings:\s+(\w+) time =\s+([\d.]+) ms`)
+
+// parseTimings reads the timings printed by whisper.cpp.
+func parseTimings(text string) Timings {
+	var t Timings
+	fields := map[string]*float64{
+		"load":   &t.Load,
+		"mel":    &t.Mel,
+		"sample": &t.Sample,
+		"encode": &t.Encode,
+		"decode": &t.Decode,
+		"batchd": &t.Batchd,
+		"prompt": &t.Prompt,
+		"total":  &t.Total,
+	}
+	for _, m := range timingPattern.FindAllStringSubmatch(text, -1) {
+		field, ok := fields[m[1]]
+		if !ok {
+			continue
+		}
+		if v, err := strconv.ParseFloat(m[2], 64); err == nil {
+			*field = v
+		}
+	}
+	return t
+}
+
+// cpuFeatures returns the features enabled in the system info of whisper.cpp,
+// e.g. "AVX = 1 | AVX2 = 1 | NEON = 0".
+func cpuFeatures(info string) []string {
+	features := []string{}
+	for _, part := range strings.Split(info, "|") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(value) != "1" {
+			continue
+		}
+		features = append(features, strings.TrimSpace(name))
+	}
+	return features
+}
+
+// Report returns the report of the run, nil before the audio is decoded.
+func (e *Engine) Report() *Report {
+	return e.report
+}
+
+// SaveReport saves the report of the run as <name>.report.json through the sink.
+// It isn't written to the standard output, where it would mix with the transcripts.
+func (e *Engine) SaveReport() error {
+	if e.report == nil {
+		return nil
+	}
+	if _, ok := e.sink.(*sink.Writer); ok {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(e.report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	location, err := e.sink.Write(e.parent, e.getOutputPath(reportExt), data)
+	if err != nil {
+		return err
+	}
+	e.logger(PhaseSave).Info().
+		Str("output-path", location).
+		Float64("realtime-factor", e.report.RealtimeFactor).
+		Msg("save run report")
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package whisper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTimings(t *testing.T) {
+	text := `
+whisper_print_timings:     load time =   120.50 ms
+whisper_print_timings:     fallbacks =   0 p /   0 h
+whisper_print_timings:      mel time =    15.25 ms
+whisper_print_timings:   sample time =    30.00 ms /   100 runs (    0.30 ms per run)
+whisper_print_timings:   encode time =  1200.00 ms /     2 runs (  600.00 ms per run)
+whisper_print_timings:   decode time =    10.00 ms /     5 runs (    2.00 ms per run)
+whisper_print_timings:   batchd time =   400.00 ms /    90 runs (    4.44 ms per run)
+whisper_print_timings:   prompt time =    50.00 ms /     2 runs (   25.00 ms per run)
+whisper_print_timings:    total time =  1800.75 ms
+`
+	want := Timings{
+		Load:   120.5,
+		Mel:    15.25,
+		Sample: 30,
+		Encode: 1200,
+		Decode: 10,
+		Batchd: 400,
+		Prompt: 50,
+		Total:  1800.75,
+	}
+	if got := parseTimings(text); got != want {
+		t.Errorf("parseTimings() = %+v, want %+v", got, want)
+	}
+	if got := parseTimings("unrelated log line"); got != (Timings{}) {
+		t.Errorf("parseTimings() = %+v, want zero timings", got)
+	}
+}
+
+func TestCpuFeatures(t *testing.T) {
+	tests := []struct {
+		name string
+		info string
+		want []string
+	}{
+		{
+			name: "x86",
+			info: "system_info: n_threads = 4 / 8 | AVX = 1 | AVX2 = 1 | AVX512 = 0 | FMA = 1 | NEON = 0 | BLAS = 0 |\n",
+			want: []string{"AVX", "AVX2", "FMA"},
+		},
+		{
+			name: "nothing enabled",
+			info: "system_info: n_threads = 4 / 8 | NEON = 0 |",
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cpuFeatures(tt.info); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cpuFeatures() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package whisper
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/appleboy/go-whisper/config"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule is a replacement of the rules file.
+type Rule struct {
+	Find       string `yaml:"find"`
+	Replace    string `yaml:"replace"`
+	Regex      bool   `yaml:"regex"`       // Regex is a Go regular expression, $1 or ${name} in Replace are the capture groups.
+	Word       bool   `yaml:"word"`        // Word only matches the text not touching another letter or digit.
+	IgnoreCase bool   `yaml:"ignore-case"` // IgnoreCase matches any case.
+}
+
+// RuleSet is the content of a rules file. The rules are applied in order,
+// the ones of every language first, then the ones of the transcript language.
+type RuleSet struct {
+	Rules     []Rule            `yaml:"rules"`
+	Languages map[string][]Rule `yaml:"languages"`
+}
+
+// rule is a compiled replacement.
+type rule struct {
+	Rule
+	re *regexp.Regexp
+}
+
+// rules replaces the text of the segments before they are saved.
+type rules struct {
+	common    []*rule
+	languages map[string][]*rule
+	dryRun    bool
+	log       *zerolog.Logger
+}
+
+// newRules reads and compiles the rules file, nil without a file.
+func newRules(cfg *config.Rules) (*rules, error) {
+	if cfg.File == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(cfg.File)
+	if err != nil {
+		return nil, err
+	}
+	var set RuleSet
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse rules file %s: %w", cfg.File, err)
+	}
+
+	r := &rules{
+		languages: map[string][]*rule{},
+		dryRun:    cfg.DryRun,
+		log:       &log.Logger,
+	}
+	if r.common, err = compileRules(set.Rules); err != nil {
+		return nil, fmt.Errorf("rules file %s: %w", cfg.File, err)
+	}
+	for lang, list := range set.Languages {
+		if lang == "auto" {
+			return nil, fmt.Errorf("rules file %s: the rules of every language go under rules", cfg.File)
+		}
+		if err := config.ValidateLanguage(lang); err != nil {
+			return nil, fmt.Errorf("rules file %s: %w", cfg.File, err)
+		}
+		if r.languages[lang], err = compileRules(list); err != nil {
+			return nil, fmt.Errorf("rules file %s: language %s: %w", cfg.File, lang, err)
+		}
+	}
+
+	return r, nil
+}
+
+func compileRules(list []Rule) ([]*rule, error) {
+	compiled := make([]*rule, 0, len(list))
+	for i, r := range list {
+		if r.Find == "" {
+			return nil, fmt.Errorf("rule %d: find is required", i+1)
+		}
+		pattern := r.Find
+		if !r.Regex {
+			pattern = regexp.QuoteMeta(pattern)
+		}
+		if r.IgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		compiled = append(compiled, &rule{Rule: r, re: re})
+	}
+	return compiled, nil
+}
+
+// apply returns the segments with the replacements of the language. In a
+// dry run the changes are only logged and the segments are returned as is.
+func (r *rules) apply(segments []whisper.Segment, language string) []whisper.Segment {
+	list := append(append([]*rule{}, r.common...), r.languages[language]...)
+	if len(list) == 0 {
+		return segments
+	}
+
+	changed := 0
+	result := make([]whisper.Segment, len(segments))
+	for i, segment := range segments {
+		text := segment.Text
+		for _, rl := range list {
+			text = rl.replace(text)
+		}
+		if text != segment.Text {
+			changed++
+			r.log.Info().
+				Dur("start", segment.Start).
+				Dur("end", segment.End).
+				Str("before", segment.Text).
+				Str("after", text).
+				Bool("dry-run", r.dryRun).
+				Msg("replace text")
+		}
+		if !r.dryRun {
+			segment.Text = text
+		}
+		result[i] = segment
+	}
+
+	r.log.Info().
+		Str("language", language).
+		Int("rules", len(list)).
+		Int("segments", changed).
+		Bool("dry-run", r.dryRun).
+		Msg("apply rules")
+	return result
+}
+
+// replace replaces the matches of the rule in the text. A regex replacement
+// expands the capture groups, a literal one is inserted as is.
+func (r *rule) replace(text string) string {
+	matches := r.re.FindAllStringSubmatchIndex(text, -1)
+
+	var (
+		out  []byte
+		last int
+		done bool
+	)
+	for _, m := range matches {
+		if r.Word && !wholeWord(text, m[0], m[1]) {
+			continue
+		}
+		out = append(out, text[last:m[0]]...)
+		if r.Regex {
+			out = r.re.ExpandString(out, r.Replace, text, m)
+		} else {
+			out = append(out, r.Replace...)
+		}
+		last, done = m[1], true
+	}
+	if !done {
+		return text
+	}
+	return string(append(out, text[last:]...))
+}
+
+// wholeWord reports whether text[start:end] doesn't continue a word on either
+// side. Unlike \b, it works for any script and for matches ending with a
+// symbol, e.g. C++.
+func wholeWord(text string, start, end int) bool {
+	if start > 0 && start < len(text) {
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		first, _ := utf8.DecodeRuneInString(text[start:])
+		if isWordChar(before) && isWordChar(first) {
+			return false
+		}
+	}
+	if end > start && end < len(text) {
+		last, _ := utf8.DecodeLastRuneInString(text[:end])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if isWordChar(last) && isWordChar(after) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,174 @@
+package whisper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+	"github.com/rs/zerolog"
+)
+
+func TestRuleReplace(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		text string
+		want string
+	}{
+		{
+			name: "literal",
+			rule: Rule{Find: "gonna", Replace: "going to"},
+			text: " I'm gonna go, Gonna.",
+			want: " I'm going to go, Gonna.",
+		},
+		{
+			name: "literal keeps the dollar",
+			rule: Rule{Find: "USD", Replace: "$1"},
+			text: " 5 USD",
+			want: " 5 $1",
+		},
+		{
+			name: "ignore case",
+			rule: Rule{Find: "gonna", Replace: "going to", IgnoreCase: true},
+			text: " Gonna go",
+			want: " going to go",
+		},
+		{
+			name: "word",
+			rule: Rule{Find: "cat", Replace: "dog", Word: true},
+			text: " cat, concatenate, cats, cat",
+			want: " dog, concatenate, cats, dog",
+		},
+		{
+			name: "word in any script",
+			rule: Rule{Find: "über", Replace: "uber", Word: true},
+			text: " über Überall darüber",
+			want: " uber Überall darüber",
+		},
+		{
+			name: "word ending with a symbol",
+			rule: Rule{Find: "C++", Replace: "C plus plus", Word: true},
+			text: " C++, ObjC++",
+			want: " C plus plus, ObjC++",
+		},
+		{
+			name: "capture groups",
+			rule: Rule{Find: `(\d+) percent`, Replace: "${1}%", Regex: true},
+			text: " 50 percent of 20 percent",
+			want: " 50% of 20%",
+		},
+		{
+			name: "named groups",
+			rule: Rule{Find: `(?P<h>\d+) o'clock`, Replace: "$h:00", Regex: true, IgnoreCase: true},
+			text: " at 5 O'CLOCK",
+			want: " at 5:00",
+		},
+		{
+			name: "no match",
+			rule: Rule{Find: "xyz", Replace: "abc"},
+			text: " hello",
+			want: " hello",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := compileRules([]Rule{tt.rule})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := compiled[0].replace(tt.text); got != tt.want {
+				t.Errorf("replace() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name:    "valid",
+			content: "rules:\n  - find: gonna\n    replace: going to\nlanguages:\n  de:\n    - find: ca.\n      replace: circa\n",
+		},
+		{
+			name:    "invalid regex",
+			content: "rules:\n  - find: '(unclosed'\n    regex: true\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing find",
+			content: "rules:\n  - replace: x\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown language",
+			content: "languages:\n  eng:\n    - find: a\n",
+			wantErr: true,
+		},
+		{
+			name:    "not yaml",
+			content: "rules: [",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "rules.yaml")
+			if err := os.WriteFile(name, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := newRules(&config.Rules{File: name}); (err != nil) != tt.wantErr {
+				t.Errorf("newRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRulesApply(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "rules.yaml")
+	content := "rules:\n  - find: gonna\n    replace: going to\n" +
+		"languages:\n  en:\n    - find: going to\n      replace: about to\n  de:\n    - find: gonna\n      replace: nie\n"
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	segments := []whisper.Segment{
+		{Start: 0, End: time.Second, Text: " We're gonna ship."},
+		{Start: time.Second, End: 2 * time.Second, Text: " Done."},
+	}
+
+	tests := []struct {
+		name     string
+		language string
+		dryRun   bool
+		want     string
+	}{
+		{name: "every language", language: "fr", want: " We're going to ship."},
+		{name: "in order", language: "en", want: " We're about to ship."},
+		{name: "dry run", language: "en", dryRun: true, want: " We're gonna ship."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newRules(&config.Rules{File: name, DryRun: tt.dryRun})
+			if err != nil {
+				t.Fatal(err)
+			}
+			nop := zerolog.Nop()
+			r.log = &nop
+
+			got := r.apply(segments, tt.language)
+			if got[0].Text != tt.want || got[0].Start != 0 || got[1].Text != " Done." {
+				t.Errorf("apply() = %+v, want %q", got, tt.want)
+			}
+			if segments[0].Text != " We're gonna ship." {
+				t.Errorf("apply() changed the input segments")
+			}
+		})
+	}
+}
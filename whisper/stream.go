@@ -0,0 +1,349 @@
+package whisper
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+	"github.com/rs/zerolog/log"
+)
+
+// Stream event types.
+const (
+	EventPartial = "partial" // EventPartial is the current guess for the pending audio, replaced by the next event.
+	EventFinal   = "final"   // EventFinal is a committed segment that won't change anymore.
+)
+
+// Stream input formats decoded without ffmpeg.
+const (
+	FormatS16LE = "s16le"
+	FormatF32LE = "f32le"
+)
+
+// maxPrompt is the length of the committed text passed as prompt to the next window.
+const maxPrompt = 200
+
+// streamChunk is the audio read at once from the input.
+const streamChunk = 100 * time.Millisecond
+
+// Event is a transcript update of a stream, written as a JSON line.
+type Event struct {
+	Type  string  `json:"type"`
+	Start float64 `json:"start"` // Start is the time in seconds since the stream started.
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Stream transcribes continuous 16 kHz mono audio. The pending audio is decoded
+// every step and reported as a partial event. When the voice activity detection
+// finds the end of an utterance, or the window is full, the audio up to that
+// point is committed as final events and dropped from the buffer.
+type Stream struct {
+	cfg    *config.Whisper
+	stream *config.Stream
+	model  whisper.Model
+	lock   sync.Locker
+	emit   func(Event) error
+
+	buf      []float32
+	offset   int // offset is the number of samples dropped before buf.
+	prompt   string
+	glossary *glossary
+}
+
+// NewStream creates a stream decoder. The lock is held while decoding, so streams
+// can share a model. Every event is passed to emit.
+func NewStream(cfg *config.Whisper, stream *config.Stream, model whisper.Model, lock sync.Locker, emit func(Event) error) *Stream {
+	return &Stream{
+		cfg:    cfg,
+		stream: stream,
+		model:  model,
+		lock:   lock,
+		emit:   emit,
+	}
+}
+
+// Run reads the audio until the end of the input and emits the transcript.
+func (s *Stream) Run(ctx context.Context, r io.Reader) error {
+	var err error
+	if s.glossary, err = newGlossary(&s.cfg.Glossary); err != nil {
+		return err
+	}
+
+	pcm, err := pcmReader(ctx, r, s.stream.Format)
+	if err != nil {
+		return err
+	}
+	defer pcm.Close()
+
+	size := 2
+	if s.stream.Format == FormatF32LE {
+		size = 4
+	}
+
+	var (
+		chunk   = make([]byte, samples(streamChunk)*size)
+		rest    []byte
+		pending int
+	)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := pcm.Read(chunk)
+		if n > 0 {
+			data := append(rest, chunk[:n]...)
+			whole := len(data) / size * size
+			s.buf = append(s.buf, pcmSamples(data[:whole], size)...)
+			rest = append(rest[:0], data[whole:]...)
+			pending += whole / size
+		}
+		if errors.Is(err, io.EOF) {
+			return s.process(true)
+		}
+		if err != nil {
+			return err
+		}
+
+		if duration(pending) >= s.stream.Step {
+			pending = 0
+			if err := s.process(false); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// process commits the finished utterances and reports the pending audio.
+func (s *Stream) process(eof bool) error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+
+	regions := detectSpeech(s.buf, &s.cfg.VAD)
+	if len(regions) == 0 {
+		// keep the padding, the speech may start right after it
+		keep := samples(s.cfg.VAD.Padding)
+		if eof {
+			keep = 0
+		}
+		if len(s.buf) > keep {
+			s.drop(len(s.buf) - keep)
+		}
+		return nil
+	}
+
+	commit := commitPoint(len(s.buf), regions, &s.cfg.VAD, s.stream.Window, eof)
+	if commit == 0 {
+		return s.partial()
+	}
+
+	return s.final(commit)
+}
+
+// commitPoint returns the number of samples that can be committed, or 0 if
+// the utterance is still going on. regions are the speech regions of n samples.
+func commitPoint(n int, regions []region, cfg *config.VAD, window time.Duration, eof bool) int {
+	if eof {
+		return n
+	}
+
+	last := regions[len(regions)-1]
+	// the last utterance is followed by a long enough silence
+	if last.end < n && n-last.end+samples(cfg.Padding) >= samples(cfg.MinSilence) {
+		return last.end
+	}
+
+	if window > 0 && n >= samples(window) {
+		// commit before the ongoing utterance if possible, otherwise cut it
+		if last.start > 0 {
+			return last.start
+		}
+		return n
+	}
+
+	return 0
+}
+
+// partial decodes the pending audio and emits it as a single partial event.
+func (s *Stream) partial() error {
+	segments, err := s.decode(s.buf)
+	if err != nil {
+		return err
+	}
+
+	texts := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		texts = append(texts, strings.TrimSpace(segment.Text))
+	}
+	text := strings.TrimSpace(strings.Join(texts, " "))
+	if text == "" {
+		return nil
+	}
+
+	return s.emit(Event{
+		Type:  EventPartial,
+		Start: duration(s.offset).Seconds(),
+		End:   duration(s.offset + len(s.buf)).Seconds(),
+		Text:  text,
+	})
+}
+
+// final decodes the first n samples, emits the segments and drops the audio.
+// The spellings close to a glossary term are corrected.
+func (s *Stream) final(n int) error {
+	segments, err := s.decode(s.buf[:n])
+	if err != nil {
+		return err
+	}
+	if s.glossary != nil {
+		segments, _ = s.glossary.correct(segments)
+	}
+
+	start := duration(s.offset)
+	for _, segment := range segments {
+		text := strings.TrimSpace(segment.Text)
+		if text == "" {
+			continue
+		}
+		if err := s.emit(Event{
+			Type:  EventFinal,
+			Start: (start + segment.Start).Seconds(),
+			End:   (start + min(segment.End, duration(n))).Seconds(),
+			Text:  text,
+		}); err != nil {
+			return err
+		}
+		s.remember(text)
+	}
+
+	s.drop(n)
+	return nil
+}
+
+// remember keeps the end of the committed text as prompt for the next window.
+func (s *Stream) remember(text string) {
+	prompt := []rune(strings.TrimSpace(s.prompt + " " + text))
+	if len(prompt) > maxPrompt {
+		prompt = prompt[len(prompt)-maxPrompt:]
+	}
+	s.prompt = string(prompt)
+}
+
+// drop removes the first n samples of the buffer.
+func (s *Stream) drop(n int) {
+	s.buf = append(s.buf[:0:0], s.buf[n:]...)
+	s.offset += n
+}
+
+// decode runs whisper over the samples with the glossary and the committed text as prompt.
+func (s *Stream) decode(data []float32) ([]whisper.Segment, error) {
+	// whisper ignores less than one second of audio
+	if least := samples(time.Second); len(data) < least {
+		data = append(append([]float32{}, data...), make([]float32, least-len(data))...)
+	}
+
+	if s.lock != nil {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+	}
+
+	ctx, err := s.model.NewContext()
+	if err != nil {
+		return nil, err
+	}
+	if err := configure(ctx, s.model, s.cfg); err != nil {
+		return nil, err
+	}
+	switch {
+	case s.glossary != nil:
+		ctx.SetPrompt(s.glossary.prompt(s.cfg.Prompt, s.prompt, promptBudget(s.cfg)))
+	case s.prompt != "":
+		ctx.SetPrompt(strings.TrimSpace(s.cfg.Prompt + " " + s.prompt))
+	}
+
+	if err := ctx.Process(data, nil, nil); err != nil {
+		return nil, err
+	}
+
+	var segments []whisper.Segment
+	for {
+		segment, err := ctx.NextSegment()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+
+	return segments, nil
+}
+
+// pcmReader returns the raw PCM of the input. Formats other than s16le and
+// f32le are converted to s16le by ffmpeg.
+func pcmReader(ctx context.Context, r io.Reader, format string) (io.ReadCloser, error) {
+	switch format {
+	case FormatS16LE, FormatF32LE:
+		return io.NopCloser(r), nil
+	}
+
+	args := []string{"-loglevel", "error"}
+	if format != "" && format != "auto" {
+		args = append(args, "-f", format)
+	}
+	args = append(args, "-i", "pipe:0", "-f", "s16le", "-ar", "16000", "-ac", "1", "pipe:1")
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.Stdin = r
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start ffmpeg: %w", err)
+	}
+
+	return &ffmpegReader{ReadCloser: out, cmd: cmd}, nil
+}
+
+// ffmpegReader waits for ffmpeg to exit once the output is consumed.
+type ffmpegReader struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (f *ffmpegReader) Close() error {
+	_ = f.ReadCloser.Close()
+	if err := f.cmd.Wait(); err != nil {
+		log.Debug().Err(err).Msg("ffmpeg exited")
+	}
+	return nil
+}
+
+// pcmSamples converts little-endian PCM to float samples.
+// size is 2 for signed 16-bit and 4 for 32-bit float.
+func pcmSamples(data []byte, size int) []float32 {
+	result := make([]float32, len(data)/size)
+	for i := range result {
+		b := data[i*size:]
+		if size == 4 {
+			result[i] = math.Float32frombits(binary.LittleEndian.Uint32(b))
+			continue
+		}
+		result[i] = float32(int16(binary.LittleEndian.Uint16(b))) / 32768
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+package whisper
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+)
+
+func TestCommitPoint(t *testing.T) {
+	cfg := &config.VAD{
+		MinSilence: time.Second,
+		Padding:    200 * time.Millisecond,
+	}
+	s := samples
+
+	tests := []struct {
+		name    string
+		n       int
+		regions []region
+		window  time.Duration
+		eof     bool
+		want    int
+	}{
+		{
+			name:    "utterance still going on",
+			n:       s(3 * time.Second),
+			regions: []region{{start: 0, end: s(3 * time.Second)}},
+			window:  20 * time.Second,
+		},
+		{
+			name:    "short pause",
+			n:       s(3 * time.Second),
+			regions: []region{{start: 0, end: s(2500 * time.Millisecond)}},
+			window:  20 * time.Second,
+		},
+		{
+			name:    "utterance ended",
+			n:       s(4 * time.Second),
+			regions: []region{{start: 0, end: s(2500 * time.Millisecond)}},
+			window:  20 * time.Second,
+			want:    s(2500 * time.Millisecond),
+		},
+		{
+			name: "full window commits before the ongoing utterance",
+			n:    s(20 * time.Second),
+			regions: []region{
+				{start: 0, end: s(12 * time.Second)},
+				{start: s(12500 * time.Millisecond), end: s(20 * time.Second)},
+			},
+			window: 20 * time.Second,
+			want:   s(12500 * time.Millisecond),
+		},
+		{
+			name:    "full window cuts a long utterance",
+			n:       s(20 * time.Second),
+			regions: []region{{start: 0, end: s(20 * time.Second)}},
+			window:  20 * time.Second,
+			want:    s(20 * time.Second),
+		},
+		{
+			name:    "end of stream",
+			n:       s(3 * time.Second),
+			regions: []region{{start: 0, end: s(3 * time.Second)}},
+			window:  20 * time.Second,
+			eof:     true,
+			want:    s(3 * time.Second),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commitPoint(tt.n, tt.regions, cfg, tt.window, tt.eof); got != tt.want {
+				t.Errorf("commitPoint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPcmSamples(t *testing.T) {
+	s16 := make([]byte, 6)
+	binary.LittleEndian.PutUint16(s16[0:], 0)
+	binary.LittleEndian.PutUint16(s16[2:], uint16(16384))
+	binary.LittleEndian.PutUint16(s16[4:], uint16(0x8000)) // -32768
+
+	f32 := make([]byte, 8)
+	binary.LittleEndian.PutUint32(f32[0:], math.Float32bits(0.25))
+	binary.LittleEndian.PutUint32(f32[4:], math.Float32bits(-1))
+
+	tests := []struct {
+		name string
+		data []byte
+		size int
+		want []float32
+	}{
+		{name: "s16le", data: s16, size: 2, want: []float32{0, 0.5, -1}},
+		{name: "f32le", data: f32, size: 4, want: []float32{0.25, -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pcmSamples(tt.data, tt.size); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pcmSamples() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
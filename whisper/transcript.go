@@ -0,0 +1,185 @@
+package whisper
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/sink"
+	"github.com/appleboy/go-whisper/transcript"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+// NewTranscript creates an engine rendering the segments of a transcript
+// without decoding. The post-processing, the glossary corrections, the
+// replacement rules and the maximum segment length of the configuration are
+// applied to the segments.
+func NewTranscript(cfg *config.Whisper, segments []Segment, opts ...Option) (*Engine, error) {
+	if err := CheckFormats(cfg.OutputFormat); err != nil {
+		return nil, err
+	}
+
+	e := &Engine{
+		cfg:      cfg,
+		sink:     sink.NewLocal(),
+		parent:   context.Background(),
+		segments: segments,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if cfg.Clean.Enabled {
+		clean, err := newCleaner(&cfg.Clean)
+		if err != nil {
+			return nil, err
+		}
+		clean.log = e.logger("clean")
+		e.raw = e.segments
+		// there is no audio to measure the speech energy
+		e.segments = clean.clean(e.segments, nil)
+	}
+
+	glossary, err := newGlossary(&cfg.Glossary)
+	if err != nil {
+		return nil, err
+	}
+	if glossary != nil {
+		glossary.log = e.logger("glossary")
+		e.segments, e.corrections = glossary.correct(e.segments)
+	}
+
+	rules, err := newRules(&cfg.Rules)
+	if err != nil {
+		return nil, err
+	}
+	if rules != nil {
+		rules.log = e.logger("rules")
+		e.segments = rules.apply(e.segments, e.ruleLanguage())
+	}
+
+	if cfg.MaxSegmentLength > 0 {
+		e.segments = layout(e.segments, int(cfg.MaxSegmentLength))
+		if e.raw != nil {
+			e.raw = layout(e.raw, int(cfg.MaxSegmentLength))
+		}
+	}
+
+	return e, nil
+}
+
+// ReadTranscript reads the segments of a transcript written in the json,
+// srt, vtt or csv format, selected by the file extension.
+func ReadTranscript(name string) ([]Segment, error) {
+	list, err := transcript.Read(name)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]Segment, 0, len(list))
+	for _, s := range list {
+		segment := Segment{Num: s.Num, Start: s.Start, End: s.End, Text: s.Text}
+		for _, t := range s.Tokens {
+			segment.Tokens = append(segment.Tokens, whisper.Token{
+				Id:    t.ID,
+				Text:  t.Text,
+				P:     t.P,
+				Start: t.Start,
+				End:   t.End,
+			})
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// Shift moves the segments by d, e.g. to align them with an edited video.
+// The segments ending before zero are dropped.
+func Shift(segments []Segment, d time.Duration) []Segment {
+	if d == 0 {
+		return segments
+	}
+
+	shifted := make([]Segment, 0, len(segments))
+	for _, segment := range segments {
+		if segment.End+d <= 0 {
+			continue
+		}
+		segment.Start = max(segment.Start+d, 0)
+		segment.End += d
+		if segment.Tokens != nil {
+			tokens := make([]whisper.Token, len(segment.Tokens))
+			for i, token := range segment.Tokens {
+				token.Start = max(token.Start+d, 0)
+				token.End = max(token.End+d, 0)
+				tokens[i] = token
+			}
+			segment.Tokens = tokens
+		}
+		shifted = append(shifted, segment)
+	}
+	return shifted
+}
+
+// layout splits the segments longer than maxLen characters on the word
+// boundaries. The time of a segment is shared by the parts in proportion
+// to their length.
+func layout(segments []Segment, maxLen int) []Segment {
+	var result []Segment
+	for _, segment := range segments {
+		text := strings.TrimSpace(segment.Text)
+		if utf8.RuneCountInString(text) <= maxLen {
+			result = append(result, segment)
+			continue
+		}
+
+		parts := wrap(text, maxLen)
+		total := 0
+		for _, part := range parts {
+			total += utf8.RuneCountInString(part)
+		}
+
+		start, done := segment.Start, 0
+		for i, part := range parts {
+			done += utf8.RuneCountInString(part)
+			end := (segment.Start + time.Duration(int64(segment.End-segment.Start)*int64(done)/int64(total))).Round(time.Millisecond)
+			if i == len(parts)-1 {
+				end = segment.End
+			}
+			result = append(result, Segment{Start: start, End: end, Text: " " + part})
+			start = end
+		}
+	}
+
+	for i := range result {
+		result[i].Num = i
+	}
+	return result
+}
+
+// wrap breaks the text into lines of up to maxLen characters, a longer word
+// is kept on its own line.
+func wrap(text string, maxLen int) []string {
+	var (
+		lines []string
+		line  string
+	)
+	for _, word := range strings.Fields(text) {
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= maxLen:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,95 @@
+package whisper
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+func TestReadTranscript(t *testing.T) {
+	segments := []whisper.Segment{
+		{Num: 0, Start: 0, End: 1500 * time.Millisecond, Text: " And so my fellow Americans,"},
+		{Num: 1, Start: 1500 * time.Millisecond, End: time.Hour + 2*time.Second, Text: " ask not what your country can do for you."},
+	}
+
+	for _, format := range []OutputFormat{FormatSrt, FormatVtt, FormatCSV, FormatJSON} {
+		t.Run(format.String(), func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "jfk."+format.String())
+			if err := os.WriteFile(name, []byte(render(format.String(), segments)), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ReadTranscript(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, segments) {
+				t.Errorf("ReadTranscript() = %+v, want %+v", got, segments)
+			}
+		})
+	}
+}
+
+func TestShift(t *testing.T) {
+	segments := []whisper.Segment{
+		{Start: 0, End: time.Second, Text: " a"},
+		{Start: time.Second, End: 3 * time.Second, Text: " b"},
+		{Start: 3 * time.Second, End: 4 * time.Second, Text: " c"},
+	}
+
+	tests := []struct {
+		name string
+		d    time.Duration
+		want []whisper.Segment
+	}{
+		{
+			name: "later",
+			d:    time.Second,
+			want: []whisper.Segment{
+				{Start: time.Second, End: 2 * time.Second, Text: " a"},
+				{Start: 2 * time.Second, End: 4 * time.Second, Text: " b"},
+				{Start: 4 * time.Second, End: 5 * time.Second, Text: " c"},
+			},
+		},
+		{
+			name: "earlier",
+			d:    -2 * time.Second,
+			want: []whisper.Segment{
+				{Start: 0, End: time.Second, Text: " b"},
+				{Start: time.Second, End: 2 * time.Second, Text: " c"},
+			},
+		},
+		{
+			name: "unchanged",
+			want: segments,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Shift(segments, tt.d); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Shift() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayout(t *testing.T) {
+	segments := []whisper.Segment{
+		{Start: 0, End: 2 * time.Second, Text: " Short one."},
+		{Start: 2 * time.Second, End: 6 * time.Second, Text: " aaaa bbbb cccc dddd"},
+	}
+
+	got := layout(segments, 10)
+	want := []whisper.Segment{
+		{Num: 0, Start: 0, End: 2 * time.Second, Text: " Short one."},
+		{Num: 1, Start: 2 * time.Second, End: 4 * time.Second, Text: " aaaa bbbb"},
+		{Num: 2, Start: 4 * time.Second, End: 6 * time.Second, Text: " cccc dddd"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("layout() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,186 @@
+package whisper
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+// vadFrame is the analysis window of the voice activity detection.
+const vadFrame = 30 * time.Millisecond
+
+// region is a span of samples.
+type region struct {
+	start, end int
+}
+
+// samples converts a duration to a number of samples.
+func samples(d time.Duration) int {
+	return int(d * whisper.SampleRate / time.Second)
+}
+
+// duration converts a number of samples to a duration.
+func duration(n int) time.Duration {
+	return time.Duration(n) * time.Second / whisper.SampleRate
+}
+
+// window returns the samples covered by the offset and duration.
+// A zero duration covers the rest of the audio.
+func window(n int, offset, d time.Duration) (int, int) {
+	start := min(samples(offset), n)
+	end := n
+	if d > 0 {
+		end = min(start+samples(d), n)
+	}
+	return start, end
+}
+
+// detectSpeech finds the speech regions of mono 16 kHz PCM with an energy-based
+// voice activity detection. Frames louder than the threshold (dBFS) are speech,
+// silences shorter than MinSilence are bridged, speech shorter than MinSpeech
+// is dropped and every region is widened by the padding.
+func detectSpeech(data []float32, cfg *config.VAD) []region {
+	frame := samples(vadFrame)
+	if frame == 0 || len(data) == 0 {
+		return nil
+	}
+
+	// find the frames above the energy threshold
+	var regions []region
+	for start := 0; start < len(data); start += frame {
+		end := min(start+frame, len(data))
+		if energy(data[start:end]) < cfg.Threshold {
+			continue
+		}
+		if n := len(regions); n > 0 && regions[n-1].end == start {
+			regions[n-1].end = end
+			continue
+		}
+		regions = append(regions, region{start: start, end: end})
+	}
+
+	// bridge short silences
+	minSilence := samples(cfg.MinSilence)
+	merged := regions[:0]
+	for _, r := range regions {
+		if n := len(merged); n > 0 && r.start-merged[n-1].end < minSilence {
+			merged[n-1].end = r.end
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	// drop short bursts and add the padding
+	minSpeech := samples(cfg.MinSpeech)
+	padding := samples(cfg.Padding)
+	var result []region
+	for _, r := range merged {
+		if r.end-r.start < minSpeech {
+			continue
+		}
+		r.start = max(r.start-padding, 0)
+		r.end = min(r.end+padding, len(data))
+		if n := len(result); n > 0 && r.start <= result[n-1].end {
+			result[n-1].end = r.end
+			continue
+		}
+		result = append(result, r)
+	}
+
+	return result
+}
+
+// energy returns the RMS level of the samples in dBFS.
+func energy(data []float32) float64 {
+	if len(data) == 0 {
+		return math.Inf(-1)
+	}
+
+	var sum float64
+	for _, v := range data {
+		sum += float64(v) * float64(v)
+	}
+
+	return 10 * math.Log10(sum/float64(len(data)))
+}
+
+// timeline maps the compacted speech-only audio back to the original audio.
+type timeline struct {
+	// offsets are the start of each region in the compacted audio.
+	offsets []int
+	regions []region
+}
+
+// newTimeline creates the mapping for the given speech regions.
+func newTimeline(regions []region) *timeline {
+	t := &timeline{
+		offsets: make([]int, len(regions)),
+		regions: regions,
+	}
+
+	offset := 0
+	for i, r := range regions {
+		t.offsets[i] = offset
+		offset += r.end - r.start
+	}
+
+	return t
+}
+
+// compact returns only the speech regions of the audio.
+func (t *timeline) compact(data []float32) []float32 {
+	var size int
+	for _, r := range t.regions {
+		size += r.end - r.start
+	}
+
+	out := make([]float32, 0, size)
+	for _, r := range t.regions {
+		out = append(out, data[r.start:r.end]...)
+	}
+
+	return out
+}
+
+// original converts a timestamp of the compacted audio to the original audio.
+// An end timestamp on the boundary of two regions maps to the end of the first one.
+func (t *timeline) original(d time.Duration, end bool) time.Duration {
+	if len(t.regions) == 0 {
+		return d
+	}
+
+	pos := samples(d)
+	// the last region starting at or before the position
+	i := sort.Search(len(t.offsets), func(i int) bool {
+		return t.offsets[i] > pos
+	}) - 1
+	if i < 0 {
+		i = 0
+	}
+	if end && i > 0 && pos == t.offsets[i] {
+		i--
+	}
+
+	r := t.regions[i]
+	return duration(min(r.start+pos-t.offsets[i], r.end))
+}
+
+// remap converts the segment and token timestamps to the original audio.
+func (t *timeline) remap(segment whisper.Segment) whisper.Segment {
+	segment.Start = t.original(segment.Start, false)
+	segment.End = t.original(segment.End, true)
+
+	tokens := make([]whisper.Token, len(segment.Tokens))
+	for i, token := range segment.Tokens {
+		token.Start = t.original(token.Start, false)
+		token.End = t.original(token.End, true)
+		tokens[i] = token
+	}
+	segment.Tokens = tokens
+
+	return segment
+}
@@ -0,0 +1,135 @@
+package whisper
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+
+	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+)
+
+// signal builds mono PCM from alternating silence and tone durations.
+func signal(parts ...time.Duration) []float32 {
+	var data []float32
+	for i, d := range parts {
+		n := samples(d)
+		for j := 0; j < n; j++ {
+			v := float32(0)
+			if i%2 == 1 {
+				v = float32(0.5 * math.Sin(2*math.Pi*440*float64(j)/whisper.SampleRate))
+			}
+			data = append(data, v)
+		}
+	}
+	return data
+}
+
+func TestDetectSpeech(t *testing.T) {
+	cfg := &config.VAD{
+		Threshold:  -45,
+		MinSpeech:  250 * time.Millisecond,
+		MinSilence: 500 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name    string
+		data    []float32
+		padding time.Duration
+		want    []region
+	}{
+		{
+			name: "silence only",
+			data: signal(2 * time.Second),
+			want: nil,
+		},
+		{
+			name: "two speech regions",
+			data: signal(960*time.Millisecond, 990*time.Millisecond, 990*time.Millisecond, 510*time.Millisecond),
+			want: []region{
+				{start: samples(960 * time.Millisecond), end: samples(1950 * time.Millisecond)},
+				{start: samples(2940 * time.Millisecond), end: samples(3450 * time.Millisecond)},
+			},
+		},
+		{
+			name: "bridge short silence",
+			data: signal(960*time.Millisecond, 990*time.Millisecond, 300*time.Millisecond, 510*time.Millisecond),
+			want: []region{
+				{start: samples(960 * time.Millisecond), end: samples(2760 * time.Millisecond)},
+			},
+		},
+		{
+			name: "drop short burst",
+			data: signal(960*time.Millisecond, 90*time.Millisecond, 990*time.Millisecond),
+			want: nil,
+		},
+		{
+			name:    "padding",
+			data:    signal(960*time.Millisecond, 990*time.Millisecond, 990*time.Millisecond),
+			padding: 210 * time.Millisecond,
+			want: []region{
+				{start: samples(750 * time.Millisecond), end: samples(2160 * time.Millisecond)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := *cfg
+			c.Padding = tt.padding
+			if got := detectSpeech(tt.data, &c); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detectSpeech() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeline_remap(t *testing.T) {
+	tl := newTimeline([]region{
+		{start: samples(2 * time.Second), end: samples(5 * time.Second)},
+		{start: samples(10 * time.Second), end: samples(12 * time.Second)},
+	})
+
+	data := tl.compact(make([]float32, samples(15*time.Second)))
+	if got, want := len(data), samples(5*time.Second); got != want {
+		t.Fatalf("timeline.compact() length = %d, want %d", got, want)
+	}
+
+	tests := []struct {
+		name    string
+		segment whisper.Segment
+		want    whisper.Segment
+	}{
+		{
+			name:    "first region",
+			segment: whisper.Segment{Start: 0, End: time.Second},
+			want:    whisper.Segment{Start: 2 * time.Second, End: 3 * time.Second, Tokens: []whisper.Token{}},
+		},
+		{
+			name:    "end on region boundary",
+			segment: whisper.Segment{Start: time.Second, End: 3 * time.Second},
+			want:    whisper.Segment{Start: 3 * time.Second, End: 5 * time.Second, Tokens: []whisper.Token{}},
+		},
+		{
+			name: "across regions with tokens",
+			segment: whisper.Segment{
+				Start:  2 * time.Second,
+				End:    4 * time.Second,
+				Tokens: []whisper.Token{{Start: 3 * time.Second, End: 3500 * time.Millisecond}},
+			},
+			want: whisper.Segment{
+				Start:  4 * time.Second,
+				End:    11 * time.Second,
+				Tokens: []whisper.Token{{Start: 10 * time.Second, End: 10500 * time.Millisecond}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tl.remap(tt.segment); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("timeline.remap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
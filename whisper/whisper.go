@@ -2,19 +2,30 @@ package whisper
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/appleboy/go-whisper/cache"
 	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/metrics"
+	"github.com/appleboy/go-whisper/sink"
+	"github.com/appleboy/go-whisper/tracing"
+	"github.com/appleboy/go-whisper/transcript"
 	"github.com/appleboy/go-whisper/webhook"
 
 	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
 	"github.com/go-audio/wav"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type OutputFormat string
@@ -24,35 +35,163 @@ func (f OutputFormat) String() string {
 }
 
 var (
-	FormatTxt OutputFormat = "txt"
-	FormatSrt OutputFormat = "srt"
-	FormatCSV OutputFormat = "csv"
+	FormatTxt  OutputFormat = "txt"
+	FormatSrt  OutputFormat = "srt"
+	FormatCSV  OutputFormat = "csv"
+	FormatVtt  OutputFormat = "vtt"
+	FormatJSON OutputFormat = "json"
 )
 
 type request struct {
-	Progress int `json:"progress"`
+	Progress int      `json:"progress"`
+	Status   string   `json:"status,omitempty"`
+	Outputs  []Output `json:"outputs,omitempty"`
+	Report   *Report  `json:"report,omitempty"`
+}
+
+// Output is the location of a saved transcript.
+type Output struct {
+	Format   string `json:"format"`
+	Location string `json:"location"`
+	Raw      bool   `json:"raw,omitempty"`
+}
+
+// Model is a loaded whisper model that can be shared between engines.
+type Model = whisper.Model
+
+// Segment is a transcribed segment of the audio.
+type Segment = whisper.Segment
+
+// Option configures the whisper engine.
+type Option func(*Engine)
+
+// WithSink sets the sink used to store the transcripts.
+func WithSink(s sink.Sink) Option {
+	return func(e *Engine) {
+		e.sink = s
+	}
+}
+
+// WithModel sets a loaded model shared between engines.
+// The engine doesn't close a shared model.
+func WithModel(m whisper.Model) Option {
+	return func(e *Engine) {
+		e.model = m
+		e.shared = true
+	}
+}
+
+// WithLock sets the lock held while decoding, so engines sharing a model
+// can load and save their audio concurrently but decode one at a time.
+func WithLock(l sync.Locker) Option {
+	return func(e *Engine) {
+		e.lock = l
+	}
+}
+
+// Listener receives the progress and the segments while the audio is decoded.
+type Listener interface {
+	Progress(progress int)
+	Segment(segment Segment)
+}
+
+// Phases of the engine, also the phase field of the logs.
+const (
+	PhaseConvert    = "convert"
+	PhaseLoad       = "load"
+	PhaseTranscribe = "transcribe"
+	PhaseSave       = "save"
+)
+
+// PhaseListener is a Listener also notified when the engine enters a phase.
+type PhaseListener interface {
+	Listener
+	Phase(phase string)
+}
+
+// WithListener sets the listener notified of the progress and every new segment.
+func WithListener(l Listener) Option {
+	return func(e *Engine) {
+		e.listener = l
+	}
+}
+
+// WithContext sets the context of the spans and the webhook requests.
+func WithContext(ctx context.Context) Option {
+	return func(e *Engine) {
+		e.parent = ctx
+	}
+}
+
+// WithVersion sets the version recorded in the run report.
+func WithVersion(v string) Option {
+	return func(e *Engine) {
+		e.version = v
+	}
+}
+
+// LoadModel loads the whisper model, so it can stay resident across engines.
+func LoadModel(path string) (whisper.Model, error) {
+	start := time.Now()
+	model, err := whisper.New(path)
+	if err != nil {
+		return nil, err
+	}
+	metrics.ModelLoaded(time.Since(start))
+	return model, nil
 }
 
 // New for creating a new whisper engine.
-func New(cfg *config.Whisper, webhook *webhook.Client) (*Engine, error) {
+func New(cfg *config.Whisper, webhook *webhook.Client, opts ...Option) (*Engine, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
 
-	return &Engine{
+	if err := CheckFormats(cfg.OutputFormat); err != nil {
+		return nil, err
+	}
+
+	e := &Engine{
 		cfg:     cfg,
 		webhook: webhook,
-	}, nil
+		sink:    sink.NewLocal(),
+		parent:  context.Background(),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e, nil
 }
 
 // Engine is the whisper engine.
 type Engine struct {
 	cfg      *config.Whisper
 	webhook  *webhook.Client
+	sink     sink.Sink
 	ctx      whisper.Context
 	model    whisper.Model
+	shared   bool
+	lock     sync.Locker
+	listener Listener
 	segments []whisper.Segment
+	raw      []whisper.Segment
+	timeline *timeline
 	progress int
+	outputs  []Output
+	language string
+	version  string
+	report   *Report
+	parent   context.Context
+	cache    *cache.Cache
+
+	glossary    *glossary
+	corrections []Correction
+	rules       *rules
+
+	samples      int
+	resumed      bool
+	checkpointed time.Time
 }
 
 // Transcribe converts audio to text.
@@ -60,95 +199,402 @@ func (e *Engine) Transcript() error {
 	var data []float32
 	var err error
 
-	dir, err := os.MkdirTemp("", "whisper")
+	var clean *cleaner
+	if e.cfg.Clean.Enabled {
+		if clean, err = newCleaner(&e.cfg.Clean); err != nil {
+			return err
+		}
+		clean.log = e.logger("clean")
+	}
+	if e.glossary, err = newGlossary(&e.cfg.Glossary); err != nil {
+		return err
+	}
+	if e.glossary != nil {
+		e.glossary.log = e.logger("glossary")
+	}
+	if e.rules, err = newRules(&e.cfg.Rules); err != nil {
+		return err
+	}
+	if e.rules != nil {
+		e.rules.log = e.logger("rules")
+	}
+
+	e.enter(PhaseConvert)
+	e.logger(PhaseConvert).Debug().Msg("start convert audio to wav")
+	began := time.Now()
+	_, span := tracing.Start(e.parent, "audio.convert")
+	data, err = loadAudio(e.parent, e.cfg.AudioPath)
+	tracing.End(span, err)
 	if err != nil {
 		return err
 	}
-	defer os.RemoveAll(dir)
+	conversion := time.Since(began)
 
-	convertedPath := filepath.Join(dir, "converted.wav")
-	sourcePath := e.cfg.AudioPath
-	outputPath := ""
+	if err := e.decodeAudio(data, conversion); err != nil {
+		return err
+	}
+
+	if clean != nil {
+		e.raw = e.segments
+		e.segments = clean.clean(e.segments, data)
+	}
+	if e.glossary != nil {
+		e.segments, e.corrections = e.glossary.correct(e.segments)
+	}
+	if e.rules != nil {
+		e.segments = e.rules.apply(e.segments, e.ruleLanguage())
+	}
 
-	log.Debug().Msg("start convert audio to wav")
-	if err := audioToWav(sourcePath, convertedPath); err != nil {
+	return nil
+}
+
+// ruleLanguage returns the language selecting the rules, the detected one
+// if the language isn't set.
+func (e *Engine) ruleLanguage() string {
+	if e.cfg.Language != "" && e.cfg.Language != "auto" {
+		return e.cfg.Language
+	}
+	return e.language
+}
+
+// decodeAudio reads the segments of the audio from the cache, or transcribes
+// and caches them. The model is checked first, so a cached result isn't
+// returned for options the model doesn't support.
+func (e *Engine) decodeAudio(data []float32, conversion time.Duration) error {
+	if e.needsMultilingual() {
+		if err := e.loadModel(); err != nil {
+			return err
+		}
+	}
+
+	key := e.cacheKey(data)
+	if e.loadCache(key) {
+		e.report = &Report{
+			Version:     e.version,
+			Model:       e.cfg.Model,
+			Threads:     e.cfg.Threads,
+			CPUFeatures: []string{},
+			Input:       duration(len(data)).Seconds(),
+			Conversion:  float64(conversion.Microseconds()) / 1000,
+			Cached:      true,
+		}
+		return nil
+	}
+
+	if err := e.transcribe(data, conversion); err != nil {
 		return err
 	}
-	outputPath = convertedPath
+	e.storeCache(key)
+	return nil
+}
 
-	// Open the WAV file
-	fh, err := os.Open(outputPath)
-	if err != nil {
+// loadModel loads the model unless a resident model is shared, and checks
+// that it supports the options.
+func (e *Engine) loadModel() error {
+	if e.model == nil {
+		var err error
+		e.enter(PhaseLoad)
+		_, span := tracing.Start(e.parent, "model.load", trace.WithAttributes(attribute.String("model", e.cfg.Model)))
+		e.model, err = LoadModel(e.cfg.Model)
+		tracing.End(span, err)
+		if err != nil {
+			return err
+		}
+	}
+
+	return e.checkModel()
+}
+
+// transcribe loads the model and decodes the configured window of the audio,
+// from the checkpoint of an interrupted run if resumed.
+func (e *Engine) transcribe(data []float32, conversion time.Duration) error {
+	if err := e.loadModel(); err != nil {
 		return err
 	}
-	defer fh.Close()
 
-	// Load the model
-	e.model, err = whisper.New(e.cfg.Model)
+	e.samples = len(data)
+	// apply the offset and duration before skipping the silence,
+	// so they still refer to the original audio.
+	start, end := window(len(data), e.cfg.Offset, e.cfg.Duration)
+	if e.cfg.Checkpoint.Resume {
+		start = e.resume(start, end)
+	}
+
+	if !e.resumed || start < end {
+		if err := e.process(data, start, end, conversion); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// process decodes the [start, end) window of the audio, skipping the silence
+// if the voice activity detection is enabled.
+func (e *Engine) process(data []float32, start, end int, conversion time.Duration) error {
+	input := duration(len(data))
+	// the audio decoded in this run, without the skipped window, the resumed
+	// part or the silence
+	decoded := duration(end - start)
+
+	if e.cfg.VAD.Enabled {
+		regions := detectSpeech(data[start:end], &e.cfg.VAD)
+		for i := range regions {
+			regions[i].start += start
+			regions[i].end += start
+		}
+		e.timeline = newTimeline(regions)
+		speech := e.timeline.compact(data)
+		logger := e.logger("vad")
+		logger.Info().
+			Dur("audio", duration(len(data))).
+			Dur("speech", duration(len(speech))).
+			Int("regions", len(regions)).
+			Msg("voice activity detection")
+		if len(speech) == 0 {
+			logger.Warn().Msg("no speech detected")
+			return nil
+		}
+		data = speech
+		decoded = duration(len(speech))
+	}
+
+	var err error
+	e.ctx, err = e.model.NewContext()
 	if err != nil {
 		return err
 	}
-	defer e.model.Close()
 
-	// Decode the WAV file - load the full buffer
-	dec := wav.NewDecoder(fh)
-	if buf, err := dec.FullPCMBuffer(); err != nil {
+	info := e.ctx.SystemInfo()
+	e.logger(PhaseLoad).Info().Msgf("%s", info)
+	e.report = &Report{
+		Version:     e.version,
+		Model:       e.cfg.Model,
+		Threads:     e.cfg.Threads,
+		CPUFeatures: cpuFeatures(info),
+		SystemInfo:  strings.TrimSpace(info),
+		Audio:       decoded.Seconds(),
+		Input:       input.Seconds(),
+		Conversion:  float64(conversion.Microseconds()) / 1000,
+	}
+
+	if err := configure(e.ctx, e.model, e.cfg); err != nil {
 		return err
-	} else if dec.SampleRate != whisper.SampleRate {
-		return fmt.Errorf("unsupported sample rate: %d", dec.SampleRate)
-	} else if dec.NumChans != 1 {
-		return fmt.Errorf("unsupported number of channels: %d", dec.NumChans)
-	} else {
-		data = buf.AsFloat32Buffer().Data
+	}
+	if e.resumed {
+		e.ctx.SetPrompt(resumePrompt(e.cfg.Prompt, e.segments))
+	}
+	// the speech is decoded whole, the window was applied before the detection
+	from, to := start, end
+	if e.cfg.VAD.Enabled {
+		from, to = 0, len(data)
 	}
 
-	e.ctx, err = e.model.NewContext()
-	if err != nil {
+	e.checkpointed = time.Now()
+	if err := e.decode(data, from, to, decoded); err != nil {
 		return err
 	}
+	e.checkpoint(duration(end), true)
+
+	return nil
+}
+
+// logger returns the logger of the engine context, e.g. with the job and
+// the input, and the phase of the pipeline.
+func (e *Engine) logger(phase string) *zerolog.Logger {
+	l := log.Ctx(e.parent).With().Str("phase", phase).Logger()
+	return &l
+}
 
-	e.ctx.SetThreads(e.cfg.Threads)
-	e.ctx.SetSpeedup(e.cfg.SpeedUp)
-	e.ctx.SetTranslate(e.cfg.Translate)
-	e.ctx.SetPrompt(e.cfg.Prompt)
-	e.ctx.SetMaxContext(int(e.cfg.MaxContext))
+// enter notifies the listener of a new phase.
+func (e *Engine) enter(phase string) {
+	if l, ok := e.listener.(PhaseListener); ok {
+		l.Phase(phase)
+	}
+}
 
-	log.Info().Msgf("%s", e.ctx.SystemInfo())
+// configure applies the decoding options to a new whisper context.
+func configure(ctx whisper.Context, model whisper.Model, cfg *config.Whisper) error {
+	ctx.SetThreads(cfg.Threads)
+	ctx.SetSpeedup(cfg.SpeedUp)
+	ctx.SetTranslate(cfg.Translate)
+	ctx.SetPrompt(cfg.Prompt)
+	ctx.SetMaxContext(int(cfg.MaxContext))
+
+	// English-only models always decode English and reject any language
+	if cfg.Language != "" && model.IsMultilingual() {
+		if err := ctx.SetLanguage(cfg.Language); err != nil {
+			return fmt.Errorf("set language %q: %w", cfg.Language, err)
+		}
+	}
 
-	if e.cfg.Language != "" {
-		_ = e.ctx.SetLanguage(e.cfg.Language)
+	if cfg.BeamSize > 0 {
+		ctx.SetBeamSize(int(cfg.BeamSize))
 	}
 
-	if e.cfg.BeamSize > 0 {
-		e.ctx.SetBeamSize(int(e.cfg.BeamSize))
+	if cfg.EntropyThold > 0 {
+		ctx.SetEntropyThold(float32(cfg.EntropyThold))
 	}
 
-	if e.cfg.EntropyThold > 0 {
-		e.ctx.SetEntropyThold(float32(e.cfg.EntropyThold))
+	ctx.SetTemperature(float32(cfg.Temperature))
+	ctx.SetTemperatureFallback(float32(cfg.TemperatureInc))
+	if cfg.BestOf > 0 {
+		ctx.SetBestOf(int(cfg.BestOf))
 	}
+	ctx.SetLogprobThold(float32(cfg.LogprobThold))
+	ctx.SetNoSpeechThold(float32(cfg.NoSpeechThold))
+
+	ctx.SetSplitOnWord(cfg.SplitOnWord)
+	ctx.SetMaxSegmentLength(cfg.MaxSegmentLength)
+	ctx.SetMaxTokensPerSegment(cfg.MaxTokens)
+	// the segment length is measured with the token timestamps
+	ctx.SetTokenTimestamps(cfg.TokenTimestamps || cfg.MaxSegmentLength > 0)
+	ctx.SetTokenThreshold(float32(cfg.TokenThold))
+	ctx.SetTokenSumThreshold(float32(cfg.TokenSumThold))
+	ctx.SetAudioCtx(cfg.AudioCtx)
 
-	log.Debug().Msg("start transcribe process")
+	return nil
+}
+
+// decode runs whisper over the [start, end) window of the audio and identifies
+// the language. With a glossary the window is decoded in chunks.
+// A shared model decodes one audio at a time, so the lock is held meanwhile.
+// audio is the duration of the decoded audio, used for the real-time factor.
+func (e *Engine) decode(data []float32, start, end int, audio time.Duration) error {
+	if e.lock != nil {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+	}
+
+	e.enter(PhaseTranscribe)
+	logger := e.logger(PhaseTranscribe)
+	logger.Debug().Msg("start transcribe process")
 	e.ctx.ResetTimings()
-	if err := e.ctx.Process(data, e.cbSegment(), e.cbProgress()); err != nil {
+	began := time.Now()
+	_, span := tracing.Start(e.parent, "whisper.process", trace.WithAttributes(
+		attribute.Float64("audio.seconds", audio.Seconds()),
+		attribute.Int("threads", int(e.cfg.Threads)),
+	))
+	var err error
+	if e.glossary != nil {
+		err = e.decodeChunks(data, start, end)
+	} else {
+		e.ctx.SetOffset(duration(start))
+		// a zero duration decodes the rest of the audio
+		if end < len(data) {
+			e.ctx.SetDuration(duration(end - start))
+		} else {
+			e.ctx.SetDuration(0)
+		}
+		err = e.ctx.Process(data, e.cbSegment(), e.cbProgress())
+	}
+	tracing.End(span, err)
+	if err != nil {
 		return err
 	}
-	e.ctx.PrintTimings()
+	metrics.Decoded(audio, time.Since(began))
+	timings := parseTimings(captureLogs(e.ctx.PrintTimings))
+	if e.report != nil {
+		e.report.Timings = timings
+		if audio > 0 {
+			e.report.RealtimeFactor = timings.Total / 1000 / audio.Seconds()
+		}
+	}
+
+	if lang, err := e.detectLanguage(); err != nil {
+		logger.Warn().Err(err).Msg("detect language error")
+	} else {
+		e.language = lang
+		logger.Info().Str("language", lang).Msg("detected language")
+	}
 
 	return nil
 }
 
+// checkModel returns an error if the options need a multilingual model
+// but the loaded model is English-only.
+func (e *Engine) checkModel() error {
+	if e.model.IsMultilingual() {
+		return nil
+	}
+
+	if e.cfg.Translate {
+		return fmt.Errorf("translate requires a multilingual model, %s is English-only", e.cfg.Model)
+	}
+	if e.cfg.Language != "" && e.cfg.Language != "auto" && e.cfg.Language != "en" {
+		return fmt.Errorf("language %q requires a multilingual model, %s is English-only", e.cfg.Language, e.cfg.Model)
+	}
+
+	return nil
+}
+
+// needsMultilingual reports whether the options require a multilingual model.
+func (e *Engine) needsMultilingual() bool {
+	if e.cfg.Translate {
+		return true
+	}
+	return e.cfg.Language != "" && e.cfg.Language != "auto" && e.cfg.Language != "en"
+}
+
+// loadAudio converts the audio to 16 kHz mono wav and returns the PCM samples.
+func loadAudio(ctx context.Context, path string) ([]float32, error) {
+	dir, err := os.MkdirTemp("", "whisper")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	convertedPath := filepath.Join(dir, "converted.wav")
+
+	start := time.Now()
+	if err := audioToWav(ctx, path, convertedPath); err != nil {
+		return nil, err
+	}
+	metrics.Converted(time.Since(start))
+
+	// Open the WAV file
+	fh, err := os.Open(convertedPath)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	// Decode the WAV file - load the full buffer
+	dec := wav.NewDecoder(fh)
+	buf, err := dec.FullPCMBuffer()
+	if err != nil {
+		return nil, err
+	}
+	if dec.SampleRate != whisper.SampleRate {
+		return nil, fmt.Errorf("unsupported sample rate: %d", dec.SampleRate)
+	}
+	if dec.NumChans != 1 {
+		return nil, fmt.Errorf("unsupported number of channels: %d", dec.NumChans)
+	}
+
+	return buf.AsFloat32Buffer().Data, nil
+}
+
 // cbSegment is a method of the Engine struct that returns a function.
 // The function takes a segment whisper.Segment as input and returns nothing.
-// It appends the given segment to the segments field of the Engine struct.
+// It maps the timestamps back to the original audio if the silence was skipped,
+// and appends the given segment to the segments field of the Engine struct.
 // If the PrintSegment field in the configuration is true, it prints the segment.
 // The segment is printed with the start and end time truncated to milliseconds.
 func (e *Engine) cbSegment() func(segment whisper.Segment) {
 	return func(segment whisper.Segment) {
+		if e.timeline != nil {
+			segment = e.timeline.remap(segment)
+		}
 		e.segments = append(e.segments, segment)
+		e.checkpoint(segment.End, false)
+		if e.listener != nil {
+			e.listener.Segment(segment)
+		}
 		if !e.cfg.PrintSegment {
 			return
 		}
-		log.Info().Msgf(
+		e.logger(PhaseTranscribe).Info().Msgf(
 			"[%6s -> %6s] %s",
 			segment.Start.Truncate(time.Millisecond),
 			segment.End.Truncate(time.Millisecond),
@@ -172,16 +618,19 @@ func (e *Engine) cbProgress() func(progress int) {
 			return
 		}
 		e.progress = progress
+		if e.listener != nil {
+			e.listener.Progress(progress)
+		}
 		if e.cfg.PrintProgress {
-			log.Info().Msgf("current progress: %d%%", progress)
+			e.logger(PhaseTranscribe).Info().Msgf("current progress: %d%%", progress)
 		}
 
 		// send webhook
 		if e.webhook != nil {
-			if err := e.webhook.Send(context.Background(), &request{
+			if err := e.webhook.Send(e.parent, &request{
 				Progress: progress,
 			}); err != nil {
-				log.Error().Err(err).Msg("send webhook error")
+				e.logger("webhook").Error().Err(err).Msg("send webhook error")
 			}
 		}
 	}
@@ -211,45 +660,201 @@ func (e *Engine) getOutputPath(format string) string {
 	return path.Join(folder, strings.TrimSuffix(filename, ext)+"."+format)
 }
 
-// Save saves the text to a file.
+// Save saves the text through the sink.
 // It takes a format string as input and returns an error.
 // It gets the output path for the converted audio file based on the given format.
+// The uncleaned segments are saved as well if the raw output is kept.
 func (e *Engine) Save(format string) error {
-	outputPath := e.getOutputPath(format)
-	log.Info().
-		Str("output-path", outputPath).
+	e.enter(PhaseSave)
+	if err := e.save(format, format, e.segments, false); err != nil {
+		return err
+	}
+
+	if e.cfg.Clean.KeepRaw && e.raw != nil {
+		return e.save("raw."+format, format, e.raw, true)
+	}
+
+	return nil
+}
+
+func (e *Engine) save(ext, format string, segments []whisper.Segment, raw bool) (err error) {
+	ctx, span := tracing.Start(e.parent, "output.save", trace.WithAttributes(
+		attribute.String("format", format),
+		attribute.Bool("raw", raw),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	outputPath := e.getOutputPath(ext)
+	if path.Clean(outputPath) == path.Clean(e.cfg.AudioPath) {
+		return fmt.Errorf("output %s would overwrite the input, set another output folder or filename", outputPath)
+	}
+	text := render(format, segments)
+	if OutputFormat(format) == FormatJSON && !raw {
+		// the substitutions only apply to the corrected segments
+		text = renderJSONCorrections(segments, e.corrections)
+	}
+
+	location, err := e.sink.Write(ctx, outputPath, []byte(text))
+	if err != nil {
+		return err
+	}
+	e.logger(PhaseSave).Info().
+		Str("output-path", location).
 		Str("output-format", format).
 		Msg("save text to file")
-	text := ""
-	switch OutputFormat(format) {
-	case FormatSrt:
-		for i, segment := range e.segments {
-			text += fmt.Sprintf("%d\n", i+1)
-			text += fmt.Sprintf("%s --> %s\n", srtTimestamp(segment.Start), srtTimestamp(segment.End))
-			text += segment.Text + "\n\n"
 
+	e.outputs = append(e.outputs, Output{
+		Format:   format,
+		Location: location,
+		Raw:      raw,
+	})
+
+	return nil
+}
+
+// renderers are the registered output formats.
+var renderers = map[OutputFormat]func(segments []whisper.Segment) string{
+	FormatTxt:  renderTxt,
+	FormatSrt:  renderSrt,
+	FormatCSV:  renderCSV,
+	FormatVtt:  renderVtt,
+	FormatJSON: renderJSON,
+}
+
+// Formats returns the registered output formats.
+func Formats() []string {
+	formats := make([]string, 0, len(renderers))
+	for format := range renderers {
+		formats = append(formats, format.String())
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// CheckFormats returns an error if an output format isn't registered.
+func CheckFormats(formats []string) error {
+	for _, format := range formats {
+		if _, ok := renderers[OutputFormat(format)]; !ok {
+			return fmt.Errorf("unsupported output format %q, supported formats: %s",
+				format, strings.Join(Formats(), ", "))
 		}
-	case FormatTxt:
-		for _, segment := range e.segments {
-			text += segment.Text
+	}
+	return nil
+}
+
+// render converts the segments to the given format.
+func render(format string, segments []whisper.Segment) string {
+	fn, ok := renderers[OutputFormat(format)]
+	if !ok {
+		return ""
+	}
+	return fn(segments)
+}
+
+func renderSrt(segments []whisper.Segment) string {
+	text := ""
+	for i, segment := range segments {
+		text += fmt.Sprintf("%d\n", i+1)
+		text += fmt.Sprintf("%s --> %s\n", srtTimestamp(segment.Start), srtTimestamp(segment.End))
+		text += segment.Text + "\n\n"
+	}
+	return text
+}
+
+func renderTxt(segments []whisper.Segment) string {
+	text := ""
+	for _, segment := range segments {
+		text += segment.Text
+	}
+	return text
+}
+
+func renderCSV(segments []whisper.Segment) string {
+	text := "start,end,text\n"
+	for _, segment := range segments {
+		text += fmt.Sprintf("%s,%s,\"%s\"\n", segment.Start, segment.End, segment.Text)
+	}
+	return text
+}
+
+func renderVtt(segments []whisper.Segment) string {
+	text := "WEBVTT\n\n"
+	for _, segment := range segments {
+		text += fmt.Sprintf("%s --> %s\n", vttTimestamp(segment.Start), vttTimestamp(segment.End))
+		text += strings.TrimSpace(segment.Text) + "\n\n"
+	}
+	return text
+}
+
+func renderJSON(segments []whisper.Segment) string {
+	return renderJSONCorrections(segments, nil)
+}
+
+// renderJSONCorrections renders the segments with the glossary substitutions.
+func renderJSONCorrections(segments []whisper.Segment, corrections []Correction) string {
+	doc := transcript.JSON{Segments: make([]transcript.JSONSegment, 0, len(segments))}
+	for _, c := range corrections {
+		doc.Corrections = append(doc.Corrections, transcript.JSONCorrection{
+			Start: c.Start.Seconds(),
+			End:   c.End.Seconds(),
+			From:  c.From,
+			To:    c.To,
+		})
+	}
+	for i, segment := range segments {
+		s := transcript.JSONSegment{
+			ID:    i,
+			Start: segment.Start.Seconds(),
+			End:   segment.End.Seconds(),
+			Text:  segment.Text,
 		}
-	case FormatCSV:
-		text = "start,end,text\n"
-		for _, segment := range e.segments {
-			text += fmt.Sprintf("%s,%s,\"%s\"\n", segment.Start, segment.End, segment.Text)
+		for _, token := range segment.Tokens {
+			s.Tokens = append(s.Tokens, transcript.JSONToken{
+				ID:    token.Id,
+				Text:  token.Text,
+				P:     token.P,
+				Start: token.Start.Seconds(),
+				End:   token.End.Seconds(),
+			})
 		}
+		doc.Segments = append(doc.Segments, s)
 	}
 
-	if err := os.WriteFile(outputPath, []byte(text), 0o644); err != nil {
-		return err
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data) + "\n"
+}
+
+// Outputs returns the locations of the saved transcripts.
+func (e *Engine) Outputs() []Output {
+	return e.outputs
+}
+
+// Complete removes the checkpoint and sends the completion webhook with
+// the locations of the saved transcripts.
+func (e *Engine) Complete() {
+	e.removeCheckpoint()
+
+	if e.webhook == nil {
+		return
 	}
 
-	return nil
+	if err := e.webhook.Send(e.parent, &request{
+		Progress: 100,
+		Status:   "completed",
+		Outputs:  e.outputs,
+		Report:   e.report,
+	}); err != nil {
+		e.logger("webhook").Error().Err(err).Msg("send webhook error")
+	}
 }
 
 // Close closes the engine.
+// The model is only closed if the engine loaded it.
 func (e *Engine) Close() error {
-	if e.ctx == nil {
+	if e.model == nil || e.shared {
 		return nil
 	}
 
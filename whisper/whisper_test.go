@@ -1,12 +1,151 @@
 package whisper
 
 import (
+	"context"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/sink"
+
 	"github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
 )
 
+// fakeModel decodes without whisper.cpp, every context is a fakeContext.
+type fakeModel struct {
+	whisper.Model
+	ctx     *fakeContext
+	english bool
+}
+
+func (m *fakeModel) NewContext() (whisper.Context, error) { return m.ctx, nil }
+func (m *fakeModel) IsMultilingual() bool                 { return !m.english }
+
+// fakeContext records the decoded window and returns one segment.
+type fakeContext struct {
+	whisper.Context
+	offset, duration time.Duration
+	samples          int
+}
+
+func (c *fakeContext) SetThreads(uint)                {}
+func (c *fakeContext) SetSpeedup(bool)                {}
+func (c *fakeContext) SetTranslate(bool)              {}
+func (c *fakeContext) SetPrompt(string)               {}
+func (c *fakeContext) SetMaxContext(int)              {}
+func (c *fakeContext) SetLanguage(string) error       { return nil }
+func (c *fakeContext) SetBeamSize(int)                {}
+func (c *fakeContext) SetEntropyThold(float32)        {}
+func (c *fakeContext) SetTemperature(float32)         {}
+func (c *fakeContext) SetTemperatureFallback(float32) {}
+func (c *fakeContext) SetBestOf(int)                  {}
+func (c *fakeContext) SetLogprobThold(float32)        {}
+func (c *fakeContext) SetNoSpeechThold(float32)       {}
+func (c *fakeContext) SetSplitOnWord(bool)            {}
+func (c *fakeContext) SetMaxSegmentLength(uint)       {}
+func (c *fakeContext) SetMaxTokensPerSegment(uint)    {}
+func (c *fakeContext) SetTokenTimestamps(bool)        {}
+func (c *fakeContext) SetTokenThreshold(float32)      {}
+func (c *fakeContext) SetTokenSumThreshold(float32)   {}
+func (c *fakeContext) SetAudioCtx(uint)               {}
+func (c *fakeContext) SetOffset(d time.Duration)      { c.offset = d }
+func (c *fakeContext) SetDuration(d time.Duration)    { c.duration = d }
+func (c *fakeContext) ResetTimings()                  {}
+func (c *fakeContext) SystemInfo() string             { return "AVX = 1 | NEON = 0" }
+
+// PrintTimings logs a total of one second like whisper.cpp.
+func (c *fakeContext) PrintTimings() {
+	capture.mu.Lock()
+	defer capture.mu.Unlock()
+	if capture.active {
+		capture.buf.WriteString("whisper_print_timings:    total time =  1000.00 ms\n")
+	}
+}
+
+func (c *fakeContext) Process(data []float32, segment whisper.SegmentCallback, _ whisper.ProgressCallback) error {
+	c.samples = len(data)
+	segment(whisper.Segment{Start: c.offset, End: c.offset + time.Second, Text: " Hello"})
+	return nil
+}
+
+// newFakeEngine returns an engine decoding with a fakeContext.
+func newFakeEngine(t *testing.T, cfg *config.Whisper) (*Engine, *fakeContext) {
+	t.Helper()
+	ctx := &fakeContext{}
+	if cfg.OutputFolder == "" {
+		cfg.OutputFolder = t.TempDir()
+	}
+	if cfg.AudioPath == "" {
+		cfg.AudioPath = "audio.wav"
+	}
+	return &Engine{
+		cfg:    cfg,
+		sink:   sink.NewLocal(),
+		parent: context.Background(),
+		model:  &fakeModel{ctx: ctx},
+	}, ctx
+}
+
+func TestEngine_Report(t *testing.T) {
+	// a minute of audio
+	data := make([]float32, 60*whisper.SampleRate)
+	for i := 30 * whisper.SampleRate; i < 40*whisper.SampleRate; i++ {
+		data[i] = float32(math.Sin(float64(i) / 8))
+	}
+
+	tests := []struct {
+		name  string
+		cfg   config.Whisper
+		audio float64
+	}{
+		{
+			name:  "whole input",
+			audio: 60,
+		},
+		{
+			name:  "offset and duration",
+			cfg:   config.Whisper{Offset: 10 * time.Second, Duration: 20 * time.Second},
+			audio: 20,
+		},
+		{
+			name:  "offset to the end",
+			cfg:   config.Whisper{Offset: 45 * time.Second},
+			audio: 15,
+		},
+		{
+			name: "speech only",
+			cfg: config.Whisper{VAD: config.VAD{
+				Enabled:    true,
+				Threshold:  -40,
+				MinSpeech:  250 * time.Millisecond,
+				MinSilence: time.Second,
+			}},
+			audio: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _ := newFakeEngine(t, &tt.cfg)
+			if err := e.transcribe(data, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			r := e.Report()
+			if r == nil {
+				t.Fatal("Report() = nil")
+			}
+			// the speech regions are aligned to the detection frames
+			if math.Abs(r.Audio-tt.audio) > 0.1 || r.Input != 60 {
+				t.Errorf("Report() audio = %v, input = %v, want %v, 60", r.Audio, r.Input, tt.audio)
+			}
+			if want := 1 / r.Audio; math.Abs(r.RealtimeFactor-want) > 1e-9 {
+				t.Errorf("Report() realtime factor = %v, want %v", r.RealtimeFactor, want)
+			}
+		})
+	}
+}
+
 func TestEngine_getOutputPath(t *testing.T) {
 	type fields struct {
 		cfg      *config.Whisper
@@ -63,3 +202,28 @@ func TestEngine_getOutputPath(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		formats []string
+		wantErr bool
+	}{
+		{
+			name:    "registered formats",
+			formats: []string{"txt", "srt", "vtt", "csv", "json"},
+		},
+		{
+			name:    "unregistered format",
+			formats: []string{"txt", "docx"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckFormats(tt.formats); (err != nil) != tt.wantErr {
+				t.Errorf("CheckFormats() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
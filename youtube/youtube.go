@@ -2,28 +2,35 @@ package youtube
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
-	"net"
 	"net/http"
-	"net/url"
 	"os"
 	"path"
 	"strconv"
 	"time"
 
 	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/metrics"
+	"github.com/appleboy/go-whisper/source"
+	"github.com/appleboy/go-whisper/tracing"
 
 	"github.com/kkdai/youtube/v2"
 	ytdl "github.com/kkdai/youtube/v2/downloader"
-	"golang.org/x/net/http/httpproxy"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// retryDelay is the wait between two download attempts.
+var retryDelay = 1 * time.Second
+
 // Engine is the youtube engine.
 type Engine struct {
 	cfg   *config.Youtube
 	video *youtube.Video
+	// transport replaces the proxy aware transport, e.g. in tests.
+	transport http.RoundTripper
 }
 
 // Filename returns a sanitized filename.
@@ -35,56 +42,66 @@ func (e *Engine) Filename() string {
 	return ytdl.SanitizeFilename(e.video.Title)
 }
 
-// Download downloads youtube video.
-func (e *Engine) Download(ctx context.Context) (string, error) {
-	proxyFunc := httpproxy.FromEnvironment().ProxyFunc()
-	httpTransport := &http.Transport{
-		Proxy: func(r *http.Request) (uri *url.URL, err error) {
-			return proxyFunc(r.URL)
-		},
-		IdleConnTimeout:       60 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-		ForceAttemptHTTP2:     true,
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
+// Download downloads youtube video. A failed attempt is retried up to the
+// retry count, the error of the last attempt is returned.
+func (e *Engine) Download(ctx context.Context) (_ string, err error) {
+	httpTransport := e.transport
+	if httpTransport == nil {
+		httpTransport = source.NewTransport(e.cfg.Insecure)
 	}
 
-	if e.cfg.Insecure {
-		httpTransport.TLSClientConfig = &tls.Config{
-			InsecureSkipVerify: true,
+	ctx, span := tracing.Start(ctx, "youtube.download")
+	defer func() { tracing.End(span, err) }()
+
+	logger := log.Ctx(ctx).With().Str("phase", "download").Logger()
+	logger.Info().Str("url", config.RedactURL(e.cfg.URL)).Msg("download youtube video")
+
+	start := time.Now()
+	attempts := max(e.cfg.Retry, 1)
+	for i := 0; i < attempts; i++ {
+		span.AddEvent("attempt", trace.WithAttributes(attribute.Int("attempt", i+1)))
+		if i > 0 {
+			metrics.YoutubeRetried()
+			logger.Warn().Err(err).Int("attempt", i+1).Msg("retry youtube download")
+
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(retryDelay):
+			}
 		}
-	}
 
-	for i := 0; i < e.cfg.Retry; i++ {
-		output, err := e.download(ctx, httpTransport)
-		if err != nil {
-			return "", err
-		}
-		if output != "" {
+		var output string
+		output, err = e.download(ctx, httpTransport)
+		if err == nil {
+			metrics.YoutubeDownloaded(time.Since(start))
+			logger.Info().Str("title", e.video.Title).Dur("elapsed", time.Since(start)).Msg("youtube video downloaded")
 			return output, nil
 		}
-		time.Sleep(1 * time.Second)
 	}
 
-	return "", errors.New("youtube video can't download")
+	return "", err
 }
 
-func (e *Engine) download(ctx context.Context, trans http.RoundTripper) (string, error) {
-	folder, err := os.MkdirTemp("", "youtube")
-	if err != nil {
-		panic(err)
-	}
-
+func (e *Engine) download(ctx context.Context, trans http.RoundTripper) (output string, err error) {
 	downloader := &ytdl.Downloader{}
 	downloader.HTTPClient = &http.Client{Transport: trans}
 
-	e.video, err = downloader.GetVideo(e.cfg.URL)
+	video, err := downloader.GetVideo(e.cfg.URL)
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("get youtube video: %w", err)
 	}
+	e.video = video
+
+	folder, err := os.MkdirTemp("", "youtube")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if output == "" {
+			os.RemoveAll(folder)
+		}
+	}()
 
 	mimetype := "audio/mp4"
 	outputQuality := "tiny"
@@ -0,0 +1,66 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/appleboy/go-whisper/config"
+	"github.com/appleboy/go-whisper/metrics"
+)
+
+type failingTransport struct {
+	calls int
+}
+
+func (t *failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	t.calls++
+	return nil, errors.New("connection refused")
+}
+
+func TestDownload_Retry(t *testing.T) {
+	retryDelay = time.Millisecond
+	t.Cleanup(func() { retryDelay = time.Second })
+
+	trans := &failingTransport{}
+	e := &Engine{
+		cfg:       &config.Youtube{URL: "https://www.youtube.com/watch?v=BaW_jenozKc", Retry: 3},
+		transport: trans,
+	}
+
+	before := retries(t)
+	if _, err := e.Download(context.Background()); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("Download() error = %v, want the error of the last attempt", err)
+	}
+	if got := retries(t) - before; got != 2 {
+		t.Errorf("retries = %v, want 2", got)
+	}
+	if trans.calls < 3 {
+		t.Errorf("requests = %d, want one per attempt", trans.calls)
+	}
+}
+
+// retries returns the youtube retries counter.
+func retries(t *testing.T) float64 {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	for _, line := range strings.Split(string(body), "\n") {
+		if v, ok := strings.CutPrefix(line, "whisper_youtube_download_retries_total "); ok {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return n
+		}
+	}
+	return 0
+}